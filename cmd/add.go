@@ -4,17 +4,21 @@ import (
 	"bufio"
 	"fmt"
 	"os"
-	"path/filepath"
 	"strings"
 
 	"github.com/naxodev/github-switch/internal/config"
+	"github.com/naxodev/github-switch/internal/ssh"
 	"github.com/spf13/cobra"
 )
 
 var (
-	addName   string
-	addEmail  string
-	addSSHKey string
+	addName    string
+	addEmail   string
+	addSSHKey  string
+	addUser    string
+	addHost    string
+	addExtends string
+	addTags    []string
 )
 
 var addCmd = &cobra.Command{
@@ -22,7 +26,8 @@ var addCmd = &cobra.Command{
 	Short: "Add a new GitHub account",
 	Long: `Add a new GitHub account configuration.
 
-You can specify options via flags or interactively.`,
+You can specify options via flags or interactively. With --extends, fields
+the profile provides are inherited and not asked for.`,
 	Args: cobra.ExactArgs(1),
 	RunE: runAdd,
 }
@@ -30,7 +35,11 @@ You can specify options via flags or interactively.`,
 func init() {
 	addCmd.Flags().StringVarP(&addName, "name", "n", "", "Git user name")
 	addCmd.Flags().StringVarP(&addEmail, "email", "e", "", "Git email address")
-	addCmd.Flags().StringVarP(&addSSHKey, "ssh-key", "k", "", "SSH key filename (in ~/.ssh/)")
+	addCmd.Flags().StringVarP(&addSSHKey, "ssh-key", "k", "", "SSH key filename (in the SSH directory)")
+	addCmd.Flags().StringVarP(&addUser, "user", "u", "", "GitHub username, used for HTTPS credentials")
+	addCmd.Flags().StringVar(&addHost, "host", "", "GitHub host (default github.com)")
+	addCmd.Flags().StringVar(&addExtends, "extends", "", "Profile or account to inherit fields from")
+	addCmd.Flags().StringSliceVarP(&addTags, "tag", "t", nil, "Tag for grouping and filtering (repeatable)")
 	rootCmd.AddCommand(addCmd)
 }
 
@@ -46,21 +55,30 @@ func runAdd(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("account '%s' already exists", accountName)
 	}
 
+	var inherited config.Account
+	if addExtends != "" {
+		cfg.AddAccount(accountName, config.Account{Extends: addExtends})
+		if err := cfg.CheckExtends(); err != nil {
+			return err
+		}
+		inherited, _ = cfg.GetAccount(accountName)
+	}
+
 	reader := bufio.NewReader(os.Stdin)
 
-	if addName == "" {
+	if addName == "" && inherited.Name == "" {
 		fmt.Print("Git user name: ")
 		addName, _ = reader.ReadString('\n')
 		addName = strings.TrimSpace(addName)
 	}
 
-	if addEmail == "" {
+	if addEmail == "" && inherited.Email == "" {
 		fmt.Print("Git email: ")
 		addEmail, _ = reader.ReadString('\n')
 		addEmail = strings.TrimSpace(addEmail)
 	}
 
-	if addSSHKey == "" {
+	if addSSHKey == "" && inherited.SSHKey == "" {
 		availableKeys, _ := listSSHKeys()
 		if len(availableKeys) > 0 {
 			fmt.Println("Available SSH keys:")
@@ -73,33 +91,46 @@ func runAdd(cmd *cobra.Command, args []string) error {
 		addSSHKey = strings.TrimSpace(addSSHKey)
 	}
 
-	if addName == "" || addEmail == "" || addSSHKey == "" {
-		return fmt.Errorf("all fields are required")
+	account := config.Account{
+		Name:    addName,
+		Email:   addEmail,
+		SSHKey:  addSSHKey,
+		User:    addUser,
+		Host:    addHost,
+		Extends: addExtends,
+		Tags:    splitTags(strings.Join(addTags, ",")),
 	}
 
-	cfg.AddAccount(accountName, config.Account{
-		Name:   addName,
-		Email:  addEmail,
-		SSHKey: addSSHKey,
-	})
+	return locked(func() error {
+		// The config may have changed while the prompts were open.
+		cfg, err := config.Load()
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+		if _, exists := cfg.GetAccount(accountName); exists {
+			return fmt.Errorf("account '%s' already exists", accountName)
+		}
 
-	if err := cfg.Save(); err != nil {
-		return fmt.Errorf("failed to save config: %w", err)
-	}
+		cfg.AddAccount(accountName, account)
+		if err := cfg.CheckExtends(); err != nil {
+			return err
+		}
+		if acc, _ := cfg.GetAccount(accountName); acc.Name == "" || acc.Email == "" || acc.SSHKey == "" {
+			return fmt.Errorf("all fields are required")
+		}
+
+		if err := cfg.Save(); err != nil {
+			return fmt.Errorf("failed to save config: %w", err)
+		}
 
-	fmt.Printf("Account '%s' added successfully.\n", accountName)
-	fmt.Printf("Config saved to: %s\n", config.GetConfigPath())
-	return nil
+		fmt.Printf("Account '%s' added successfully.\n", accountName)
+		fmt.Printf("Config saved to: %s\n", config.GetConfigPath())
+		return nil
+	})
 }
 
 func listSSHKeys() ([]string, error) {
-	home, err := os.UserHomeDir()
-	if err != nil {
-		return nil, err
-	}
-
-	sshDir := filepath.Join(home, ".ssh")
-	entries, err := os.ReadDir(sshDir)
+	entries, err := os.ReadDir(ssh.KeyDir())
 	if err != nil {
 		return nil, err
 	}
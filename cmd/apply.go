@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/naxodev/github-switch/internal/config"
+	"github.com/naxodev/github-switch/internal/git"
+	"github.com/naxodev/github-switch/internal/project"
+	"github.com/naxodev/github-switch/internal/ssh"
+	"github.com/spf13/cobra"
+)
+
+var quietApply bool
+
+var applyCmd = &cobra.Command{
+	Use:   "apply [directory]",
+	Short: "Apply the account bound to a directory to its repository",
+	Long: `Resolve the account bound to a directory (the current one by default)
+and write its identity into the repository's local Git config:
+user.name, user.email and core.sshCommand.
+
+A .github-switch marker file in the directory or one of its parents takes
+precedence over directory rules in the config. Global Git and SSH settings
+are left untouched.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runApply,
+}
+
+func init() {
+	applyCmd.Flags().BoolVarP(&quietApply, "quiet", "q", false, "Only print when the repository config changes")
+	rootCmd.AddCommand(applyCmd)
+}
+
+func runApply(cmd *cobra.Command, args []string) error {
+	dir := "."
+	if len(args) > 0 {
+		dir = args[0]
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	binding, err := project.Resolve(cfg, dir)
+	if err != nil {
+		return err
+	}
+	if binding == nil {
+		if !quietApply {
+			fmt.Println("No account is bound to this directory.")
+		}
+		return nil
+	}
+
+	account, ok := cfg.GetAccount(binding.Account)
+	if !ok {
+		return fmt.Errorf("unknown account '%s' (from %s)", binding.Account, binding.Source)
+	}
+
+	repo, err := git.RepoRoot(dir)
+	if err != nil {
+		return err
+	}
+	if repo == "" {
+		if !quietApply {
+			fmt.Printf("Account '%s' is bound here (from %s), but this is not a Git repository.\n", binding.Account, binding.Source)
+		}
+		return nil
+	}
+
+	sshCommand := ssh.Command(account.SSHKey)
+
+	desired := map[string]string{
+		"user.name":       account.Name,
+		"user.email":      account.Email,
+		"core.sshCommand": sshCommand,
+	}
+
+	changed := make(map[string]string)
+	for key, value := range desired {
+		current, err := git.GetLocalConfig(repo, key)
+		if err != nil {
+			return err
+		}
+		if current != value {
+			changed[key] = value
+		}
+	}
+
+	if len(changed) == 0 {
+		if !quietApply {
+			fmt.Printf("Repository already uses account '%s'.\n", binding.Account)
+		}
+		return nil
+	}
+
+	if err := git.UpdateLocalConfig(repo, changed); err != nil {
+		return fmt.Errorf("failed to update repository config: %w", err)
+	}
+
+	fmt.Fprintf(os.Stderr, "github-switch: using account '%s' in %s (from %s)\n", binding.Account, repo, binding.Source)
+	return nil
+}
@@ -0,0 +1,552 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/naxodev/github-switch/internal/config"
+	"github.com/naxodev/github-switch/internal/lock"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// sandbox points every file github-switch touches into a temp directory and
+// returns the global flags that select them.
+func sandbox(t *testing.T) (dir string, globalArgs []string) {
+	t.Helper()
+	dir = t.TempDir()
+	t.Setenv("HOME", dir)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(dir, "config"))
+	t.Setenv("XDG_STATE_HOME", filepath.Join(dir, "state"))
+	t.Setenv("XDG_DATA_HOME", filepath.Join(dir, "data"))
+	t.Setenv("GH_CONFIG_DIR", filepath.Join(dir, "gh"))
+	t.Setenv("GITHUB_SWITCH_CONFIG", "")
+	t.Setenv(envAccount, "")
+
+	return dir, []string{
+		"--ssh-config", filepath.Join(dir, "ssh", "config"),
+		"--gitconfig", filepath.Join(dir, "gitconfig"),
+	}
+}
+
+// run executes the root command with args and returns what it printed to
+// stdout. Flags are reset first since cobra keeps their values between runs.
+func run(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+	resetFlags(rootCmd)
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	output := make(chan string)
+	go func() {
+		var buf bytes.Buffer
+		io.Copy(&buf, r)
+		output <- buf.String()
+	}()
+
+	rootCmd.SetArgs(args)
+	err = rootCmd.Execute()
+	w.Close()
+	return <-output, err
+}
+
+// withStdin makes the commands run by fn read input from stdin.
+func withStdin(t *testing.T, input string, fn func()) {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.WriteString(input)
+	w.Close()
+
+	saved := os.Stdin
+	os.Stdin = r
+	defer func() { os.Stdin = saved; r.Close() }()
+	fn()
+}
+
+func resetFlags(cmd *cobra.Command) {
+	reset := func(f *pflag.Flag) {
+		// Setting "[]" on a slice flag would add that literal as an element.
+		if slice, ok := f.Value.(pflag.SliceValue); ok {
+			slice.Replace(nil)
+		} else {
+			f.Value.Set(f.DefValue)
+		}
+		f.Changed = false
+	}
+	cmd.PersistentFlags().VisitAll(reset)
+	cmd.Flags().VisitAll(reset)
+	for _, sub := range cmd.Commands() {
+		resetFlags(sub)
+	}
+}
+
+func TestAddSwitchAndList(t *testing.T) {
+	dir, global := sandbox(t)
+
+	if _, err := run(t, append(global, "add", "work", "--name", "Work Me", "--email", "me@work.example", "--ssh-key", "id_work")...); err != nil {
+		t.Fatalf("add work: %v", err)
+	}
+	if _, err := run(t, append(global, "add", "personal", "-n", "Me", "-e", "me@home.example", "-k", "id_personal")...); err != nil {
+		t.Fatalf("add personal: %v", err)
+	}
+	if _, err := run(t, append(global, "switch", "work", "--force")...); err != nil {
+		t.Fatalf("switch: %v", err)
+	}
+
+	sshConfig, err := os.ReadFile(filepath.Join(dir, "ssh", "config"))
+	if err != nil {
+		t.Fatalf("expected SSH config to be written: %v", err)
+	}
+	// The sandbox's SSH directory is $HOME/ssh, where the key is looked up.
+	if !strings.Contains(string(sshConfig), "IdentityFile ~/ssh/id_work") {
+		t.Errorf("expected SSH config to use id_work in the sandbox's SSH directory:\n%s", sshConfig)
+	}
+
+	gitConfig, err := os.ReadFile(filepath.Join(dir, "gitconfig"))
+	if err != nil {
+		t.Fatalf("expected gitconfig to be written: %v", err)
+	}
+	if !strings.Contains(string(gitConfig), "email = me@work.example") {
+		t.Errorf("expected gitconfig to use the work email:\n%s", gitConfig)
+	}
+
+	out, err := run(t, append(global, "list", "--output", "json")...)
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	var view listView
+	if err := json.Unmarshal([]byte(out), &view); err != nil {
+		t.Fatalf("failed to parse list output: %v\n%s", err, out)
+	}
+	if len(view.Accounts) != 2 {
+		t.Fatalf("expected 2 accounts, got %d", len(view.Accounts))
+	}
+	for _, acc := range view.Accounts {
+		if acc.Active != (acc.Account == "work") {
+			t.Errorf("unexpected active flag for %s: %v", acc.Account, acc.Active)
+		}
+	}
+
+	out, err = run(t, append(global, "prompt")...)
+	if err != nil {
+		t.Fatalf("prompt: %v", err)
+	}
+	if out != "work" {
+		t.Errorf("expected prompt 'work', got '%s'", out)
+	}
+}
+
+func TestPrompt(t *testing.T) {
+	_, global := sandbox(t)
+
+	for _, name := range []string{"work", "personal"} {
+		if _, err := run(t, append(global, "add", name, "-n", "Me", "-e", "me@"+name+".example", "-k", "id_"+name)...); err != nil {
+			t.Fatalf("add %s: %v", name, err)
+		}
+	}
+
+	out, err := run(t, append(global, "prompt")...)
+	if err != nil {
+		t.Fatalf("prompt: %v", err)
+	}
+	if out != "" {
+		t.Errorf("expected no output without an active account, got %q", out)
+	}
+
+	if _, err := run(t, append(global, "switch", "work", "--force")...); err != nil {
+		t.Fatalf("switch: %v", err)
+	}
+	out, err = run(t, append(global, "prompt", "--format", "[{{.Account}} {{.Email}}] ")...)
+	if err != nil {
+		t.Fatalf("prompt: %v", err)
+	}
+	if out != "[work me@work.example] " {
+		t.Errorf("expected the formatted active account from the state file, got %q", out)
+	}
+
+	// Set by env for the current shell only.
+	t.Setenv(envAccount, "personal")
+	out, err = run(t, append(global, "prompt")...)
+	if err != nil {
+		t.Fatalf("prompt: %v", err)
+	}
+	if out != "personal" {
+		t.Errorf("expected the shell's account to take precedence, got %q", out)
+	}
+
+	if out, err := run(t, append(global, "prompt", "-o", "json")...); err == nil {
+		t.Errorf("expected --output json to be refused rather than ignored, got %q", out)
+	}
+}
+
+func TestEditActiveAccount(t *testing.T) {
+	dir, global := sandbox(t)
+
+	if _, err := run(t, append(global, "add", "work", "-n", "Work Me", "-e", "me@work.example", "-k", "id_work")...); err != nil {
+		t.Fatalf("add: %v", err)
+	}
+	if _, err := run(t, append(global, "switch", "work", "--force")...); err != nil {
+		t.Fatalf("switch: %v", err)
+	}
+	if _, err := run(t, append(global, "edit", "work", "--email", "me@acme.example", "--rename", "acme")...); err != nil {
+		t.Fatalf("edit: %v", err)
+	}
+
+	gitConfig, err := os.ReadFile(filepath.Join(dir, "gitconfig"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(gitConfig), "email = me@acme.example") {
+		t.Errorf("expected the edit to be re-applied to gitconfig:\n%s", gitConfig)
+	}
+
+	out, err := run(t, append(global, "prompt")...)
+	if err != nil {
+		t.Fatalf("prompt: %v", err)
+	}
+	if out != "acme" {
+		t.Errorf("expected the active account to follow the rename, got '%s'", out)
+	}
+
+	if _, err := run(t, append(global, "edit", "work", "--name", "Nobody")...); err == nil {
+		t.Error("expected editing the old name to fail")
+	}
+}
+
+func TestExportAndImportBundle(t *testing.T) {
+	dir, global := sandbox(t)
+
+	if _, err := run(t, append(global, "add", "work", "-n", "Work Me", "-e", "me@work.example", "-k", "id_work")...); err != nil {
+		t.Fatalf("add: %v", err)
+	}
+
+	bundlePath := filepath.Join(dir, "accounts.json")
+	if _, err := run(t, append(global, "export", "--file", bundlePath)...); err != nil {
+		t.Fatalf("export: %v", err)
+	}
+
+	if _, err := run(t, append(global, "import", "--file", bundlePath, "--on-conflict", "rename", "--key-template", "{{.Key}}_copy")...); err != nil {
+		t.Fatalf("import: %v", err)
+	}
+
+	out, err := run(t, append(global, "list", "-o", "json")...)
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	var view listView
+	if err := json.Unmarshal([]byte(out), &view); err != nil {
+		t.Fatalf("failed to parse list output: %v\n%s", err, out)
+	}
+	if len(view.Accounts) != 2 || view.Accounts[1].Account != "work-2" || view.Accounts[1].SSHKey != "id_work_copy" {
+		t.Errorf("expected the bundle to be added as work-2 with a templated key, got %+v", view.Accounts)
+	}
+}
+
+func TestEditReappliesInheritingAccount(t *testing.T) {
+	dir, global := sandbox(t)
+
+	if _, err := run(t, append(global, "add", "work", "-n", "Work Me", "-e", "me@work.example", "-k", "id_work")...); err != nil {
+		t.Fatalf("add work: %v", err)
+	}
+	if _, err := run(t, append(global, "add", "client", "--extends", "work", "-e", "me@client.example", "-k", "id_client")...); err != nil {
+		t.Fatalf("add client: %v", err)
+	}
+	if _, err := run(t, append(global, "switch", "client", "--force")...); err != nil {
+		t.Fatalf("switch: %v", err)
+	}
+	if _, err := run(t, append(global, "edit", "work", "--name", "Renamed Me")...); err != nil {
+		t.Fatalf("edit: %v", err)
+	}
+
+	gitConfig, err := os.ReadFile(filepath.Join(dir, "gitconfig"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(gitConfig), "name = Renamed Me") || !strings.Contains(string(gitConfig), "email = me@client.example") {
+		t.Errorf("expected the inherited name change to be re-applied:\n%s", gitConfig)
+	}
+
+	if _, err := run(t, append(global, "remove", "work", "--force")...); err == nil {
+		t.Error("expected removing an extended account to fail")
+	}
+}
+
+func TestTagsFilterListAndExport(t *testing.T) {
+	dir, global := sandbox(t)
+
+	if _, err := run(t, append(global, "add", "client-a", "-n", "Me", "-e", "me@a.example", "-k", "id_a", "--tag", "client", "-t", "billable")...); err != nil {
+		t.Fatalf("add client-a: %v", err)
+	}
+	if _, err := run(t, append(global, "add", "oss", "-n", "Me", "-e", "me@oss.example", "-k", "id_oss", "--tag", "oss")...); err != nil {
+		t.Fatalf("add oss: %v", err)
+	}
+	if _, err := run(t, append(global, "edit", "oss", "--tag", "oss,client")...); err != nil {
+		t.Fatalf("edit: %v", err)
+	}
+
+	out, err := run(t, append(global, "list", "--tag", "client", "-o", "json")...)
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	var view listView
+	if err := json.Unmarshal([]byte(out), &view); err != nil {
+		t.Fatalf("failed to parse list output: %v\n%s", err, out)
+	}
+	if len(view.Accounts) != 2 {
+		t.Fatalf("expected both accounts tagged client, got %+v", view.Accounts)
+	}
+
+	out, err = run(t, append(global, "list", "-t", "client", "-t", "billable", "-o", "json")...)
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	view = listView{}
+	if err := json.Unmarshal([]byte(out), &view); err != nil {
+		t.Fatalf("failed to parse list output: %v\n%s", err, out)
+	}
+	if len(view.Accounts) != 1 || view.Accounts[0].Account != "client-a" {
+		t.Errorf("expected only client-a to carry both tags, got %+v", view.Accounts)
+	}
+
+	bundlePath := filepath.Join(dir, "oss.yaml")
+	if _, err := run(t, append(global, "export", "--tag", "oss", "--file", bundlePath)...); err != nil {
+		t.Fatalf("export: %v", err)
+	}
+	data, err := os.ReadFile(bundlePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "oss:") || strings.Contains(string(data), "client-a") {
+		t.Errorf("expected only the oss account in the bundle:\n%s", data)
+	}
+
+	if _, err := run(t, append(global, "switch", "--tag", "billable", "--force")...); err != nil {
+		t.Fatalf("switch: %v", err)
+	}
+	if out, _ := run(t, append(global, "prompt")...); out != "client-a" {
+		t.Errorf("expected the only billable account to be picked, got '%s'", out)
+	}
+}
+
+func TestReadingTokensDoesNotWaitForTheLock(t *testing.T) {
+	_, global := sandbox(t)
+	t.Setenv(envPassphrase, "secret passphrase")
+	t.Setenv(envKeyFile, "")
+
+	if _, err := run(t, append(global, "add", "work", "-n", "Work Me", "-e", "me@work.example", "-k", "id_work")...); err != nil {
+		t.Fatalf("add: %v", err)
+	}
+	withStdin(t, "ghp_work\n", func() {
+		if _, err := run(t, append(global, "token", "set", "work")...); err != nil {
+			t.Fatalf("token set: %v", err)
+		}
+	})
+
+	// Another command, such as a switch waiting for confirmation, holds the lock.
+	l, err := lock.Acquire(config.GetConfigPath()+".lock", lockTimeout)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Release()
+
+	out, err := run(t, append(global, "--lock-timeout", "50ms", "token", "get", "work")...)
+	if err != nil {
+		t.Fatalf("token get: %v", err)
+	}
+	if out != "ghp_work\n" {
+		t.Errorf("expected the stored token, got %q", out)
+	}
+
+	if _, err := run(t, append(global, "--lock-timeout", "50ms", "token", "rm", "work")...); err == nil {
+		t.Error("expected removing a token to wait for the lock")
+	}
+}
+
+func TestPromptsDoNotHoldTheLock(t *testing.T) {
+	_, global := sandbox(t)
+
+	if _, err := run(t, append(global, "add", "work", "-n", "Work Me", "-e", "me@work.example", "-k", "id_work")...); err != nil {
+		t.Fatalf("add: %v", err)
+	}
+
+	l, err := lock.Acquire(config.GetConfigPath()+".lock", lockTimeout)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Release()
+
+	// Declining writes nothing, so the lock is never needed.
+	withStdin(t, "n\n", func() {
+		out, err := run(t, append(global, "--lock-timeout", "50ms", "remove", "work")...)
+		if err != nil {
+			t.Fatalf("remove: %v", err)
+		}
+		if !strings.Contains(out, "Cancelled.") {
+			t.Errorf("expected the removal to be cancelled, got:\n%s", out)
+		}
+	})
+
+	withStdin(t, "y\n", func() {
+		if _, err := run(t, append(global, "--lock-timeout", "50ms", "remove", "work")...); err == nil {
+			t.Error("expected confirming the removal to wait for the lock")
+		}
+	})
+}
+
+func TestCredentialPrefersExecAccount(t *testing.T) {
+	_, global := sandbox(t)
+	t.Setenv(envPassphrase, "secret passphrase")
+	t.Setenv(envKeyFile, "")
+
+	for _, name := range []string{"work", "personal"} {
+		if _, err := run(t, append(global, "add", name, "-n", "Me", "-e", "me@"+name+".example", "-k", "id_"+name)...); err != nil {
+			t.Fatalf("add %s: %v", name, err)
+		}
+		withStdin(t, "ghp_"+name+"\n", func() {
+			if _, err := run(t, append(global, "token", "set", name)...); err != nil {
+				t.Fatalf("token set %s: %v", name, err)
+			}
+		})
+	}
+	if _, err := run(t, append(global, "switch", "personal", "--force")...); err != nil {
+		t.Fatalf("switch: %v", err)
+	}
+
+	// exec work -- git push runs the helper with the account in the environment.
+	t.Setenv(envAccount, "work")
+	var out string
+	withStdin(t, "protocol=https\nhost=github.com\n\n", func() {
+		var err error
+		if out, err = run(t, append(global, "credential", "get")...); err != nil {
+			t.Fatalf("credential get: %v", err)
+		}
+	})
+	if !strings.Contains(out, "password=ghp_work\n") {
+		t.Errorf("expected the token of the exec account, got:\n%s", out)
+	}
+}
+
+func TestCredentialStoreKeepsOtherUsersTokens(t *testing.T) {
+	_, global := sandbox(t)
+	t.Setenv(envPassphrase, "secret passphrase")
+	t.Setenv(envKeyFile, "")
+
+	if _, err := run(t, append(global, "add", "work", "-n", "Me", "-e", "me@work.example", "-k", "id_work", "-u", "alice")...); err != nil {
+		t.Fatalf("add: %v", err)
+	}
+	withStdin(t, "ghp_work\n", func() {
+		if _, err := run(t, append(global, "token", "set", "work")...); err != nil {
+			t.Fatalf("token set: %v", err)
+		}
+	})
+	if _, err := run(t, append(global, "switch", "work", "--force")...); err != nil {
+		t.Fatalf("switch: %v", err)
+	}
+
+	// Git reports bob's credentials; the active account only answers as a fallback.
+	for _, operation := range []string{"store", "erase"} {
+		withStdin(t, "protocol=https\nhost=github.com\nusername=bob\npassword=ghp_bob\n\n", func() {
+			if _, err := run(t, append(global, "credential", operation)...); err != nil {
+				t.Fatalf("credential %s: %v", operation, err)
+			}
+		})
+	}
+
+	out, err := run(t, append(global, "token", "get", "work")...)
+	if err != nil {
+		t.Fatalf("token get: %v", err)
+	}
+	if out != "ghp_work\n" {
+		t.Errorf("expected the token of alice to be kept, got %q", out)
+	}
+
+	withStdin(t, "protocol=http\nhost=github.com\nusername=alice\npassword=ghp_work\n\n", func() {
+		if _, err := run(t, append(global, "credential", "erase")...); err != nil {
+			t.Fatalf("credential erase: %v", err)
+		}
+	})
+	if _, err := run(t, append(global, "token", "get", "work")...); err != nil {
+		t.Errorf("expected plain HTTP requests to be ignored: %v", err)
+	}
+}
+
+func TestTokensInIncludesAreNotMoved(t *testing.T) {
+	dir, global := sandbox(t)
+	t.Setenv(envPassphrase, "secret passphrase")
+	t.Setenv(envKeyFile, "")
+
+	configDir := filepath.Join(dir, "config", "github-switch")
+	if err := os.MkdirAll(configDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	team := "accounts:\n  team:\n    ssh_key: id_team\n    name: Me\n    email: me@team.example\n    token: ghp_team\n"
+	personal := "version: 1\nincludes: [team.yaml]\naccounts:\n  work:\n    ssh_key: id_work\n    name: Me\n    email: me@work.example\n    token: ghp_work\n"
+	if err := os.WriteFile(filepath.Join(configDir, "team.yaml"), []byte(team), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(configDir, "config.yaml"), []byte(personal), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := run(t, append(global, "token", "get", "work")...)
+	if err != nil {
+		t.Fatalf("token get: %v", err)
+	}
+	if out != "ghp_work\n" {
+		t.Errorf("expected the moved token, got %q", out)
+	}
+
+	data, err := os.ReadFile(filepath.Join(configDir, "config.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "ghp_work") || strings.Contains(string(data), "id_team") {
+		t.Errorf("expected only the user's own token to be moved out of the config:\n%s", data)
+	}
+	if data, _ := os.ReadFile(filepath.Join(configDir, "team.yaml")); string(data) != team {
+		t.Errorf("expected the included file to be left alone:\n%s", data)
+	}
+	if _, err := run(t, append(global, "token", "get", "team")...); err == nil {
+		t.Error("expected the included token not to be copied into the store")
+	}
+}
+
+func TestRemoveDeletesToken(t *testing.T) {
+	_, global := sandbox(t)
+	t.Setenv(envPassphrase, "secret passphrase")
+	t.Setenv(envKeyFile, "")
+
+	add := append(global, "add", "work", "-n", "Work Me", "-e", "me@work.example", "-k", "id_work")
+	if _, err := run(t, add...); err != nil {
+		t.Fatalf("add: %v", err)
+	}
+	withStdin(t, "ghp_work\n", func() {
+		if _, err := run(t, append(global, "token", "set", "work")...); err != nil {
+			t.Fatalf("token set: %v", err)
+		}
+	})
+
+	if _, err := run(t, append(global, "remove", "work", "--force")...); err != nil {
+		t.Fatalf("remove: %v", err)
+	}
+	if _, err := run(t, add...); err != nil {
+		t.Fatalf("add again: %v", err)
+	}
+	if out, err := run(t, append(global, "token", "get", "work")...); err == nil {
+		t.Errorf("expected the new account not to inherit the old token, got %q", out)
+	}
+}
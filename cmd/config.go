@@ -0,0 +1,365 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/naxodev/github-switch/internal/config"
+	"github.com/naxodev/github-switch/internal/ssh"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Inspect and maintain the configuration file",
+}
+
+var configValidateCmd = &cobra.Command{
+	Use:   "validate [file]",
+	Short: "Check the configuration file for mistakes",
+	Long: `Check a configuration file (the active one by default) against the schema:
+required fields, email addresses, unknown keys, emails or SSH keys shared by
+several accounts, rules that refer to unknown accounts and missing key files.
+
+Each issue is reported with its line and column. Exits with a non-zero status
+when errors are found, or when warnings are found and --strict is set.`,
+	Args:        cobra.MaximumNArgs(1),
+	Annotations: map[string]string{outputAnnotation: "true"},
+	RunE:        runConfigValidate,
+}
+
+var configEditCmd = &cobra.Command{
+	Use:   "edit",
+	Short: "Edit the configuration file safely",
+	Long: `Open a copy of the configuration file in $VISUAL or $EDITOR. When the
+editor exits the copy is validated; if it has errors, they are written into
+the file as comments above the offending lines and the editor is opened
+again. The real file is only replaced once the copy passes, and not if
+another command changed it in the meantime.`,
+	Args: cobra.NoArgs,
+	RunE: runConfigEdit,
+}
+
+var configSourcesCmd = &cobra.Command{
+	Use:   "sources",
+	Short: "Show the config files in use and where each account comes from",
+	Long: `List the config files that make up the configuration, highest precedence
+first: the user's config, the files it names under includes (later ones
+first) and the files in /etc/github-switch.d (later names first). Overlays
+are read-only; an account defined in several files is taken from the first.`,
+	Args:        cobra.NoArgs,
+	Annotations: map[string]string{outputAnnotation: "true"},
+	RunE:        runConfigSources,
+}
+
+var validateStrict bool
+
+func init() {
+	configValidateCmd.Flags().BoolVar(&validateStrict, "strict", false, "Treat warnings as errors")
+	configCmd.AddCommand(configValidateCmd)
+	configCmd.AddCommand(configEditCmd)
+	configCmd.AddCommand(configSourcesCmd)
+	rootCmd.AddCommand(configCmd)
+}
+
+type validateView struct {
+	File   string         `json:"file" yaml:"file"`
+	Valid  bool           `json:"valid" yaml:"valid"`
+	Issues []config.Issue `json:"issues" yaml:"issues"`
+}
+
+func runConfigValidate(cmd *cobra.Command, args []string) error {
+	path := config.GetConfigPath()
+	if len(args) > 0 {
+		path = args[0]
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config: %w", err)
+	}
+
+	issues, err := config.Validate(data, validateOptions(data))
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	valid := true
+	for _, issue := range issues {
+		if issue.Severity == config.SeverityError || validateStrict {
+			valid = false
+		}
+	}
+
+	if structuredOutput() {
+		if issues == nil {
+			issues = []config.Issue{}
+		}
+		if err := printStructured(validateView{File: path, Valid: valid, Issues: issues}); err != nil {
+			return err
+		}
+	} else {
+		for _, issue := range issues {
+			fmt.Printf("%s:%s\n", path, issue)
+		}
+		if len(issues) == 0 {
+			fmt.Printf("%s is valid.\n", path)
+		}
+	}
+
+	if !valid {
+		return exitWithCode(cmd, 1)
+	}
+	return nil
+}
+
+func runConfigEdit(cmd *cobra.Command, args []string) error {
+	path := config.GetConfigPath()
+
+	original, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read config: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+
+	// The copy lives next to the config so it can be renamed into place.
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".config-*.yaml")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	tmpPath := tmp.Name()
+	tmp.Close()
+	defer os.Remove(tmpPath)
+
+	if err := os.WriteFile(tmpPath, original, 0o600); err != nil {
+		return fmt.Errorf("failed to write temporary file: %w", err)
+	}
+
+	reader := bufio.NewReader(os.Stdin)
+	for {
+		if err := runEditor(tmpPath); err != nil {
+			return err
+		}
+
+		edited, err := os.ReadFile(tmpPath)
+		if err != nil {
+			return fmt.Errorf("failed to read temporary file: %w", err)
+		}
+		edited = config.StripAnnotations(edited)
+
+		issues, err := config.Validate(edited, validateOptions(edited))
+		if err != nil {
+			issues = []config.Issue{config.SyntaxIssue(err)}
+		}
+
+		var errs int
+		for _, issue := range issues {
+			if issue.Severity == config.SeverityError {
+				errs++
+			}
+			fmt.Fprintf(os.Stderr, "%s:%s\n", path, issue)
+		}
+
+		if errs == 0 {
+			if bytes.Equal(edited, original) {
+				fmt.Println("No changes made.")
+				return nil
+			}
+			if err := os.WriteFile(tmpPath, edited, 0o600); err != nil {
+				return fmt.Errorf("failed to write temporary file: %w", err)
+			}
+			return locked(func() error {
+				return replaceConfig(path, tmpPath, original)
+			})
+		}
+
+		fmt.Fprintf(os.Stderr, "\nThe config has %d error(s). Edit again? [Y/n]: ", errs)
+		response, _ := reader.ReadString('\n')
+		response = strings.TrimSpace(strings.ToLower(response))
+		if response != "" && response != "y" && response != "yes" {
+			fmt.Fprintln(os.Stderr, "Discarded changes; the config was not modified.")
+			return exitWithCode(cmd, 1)
+		}
+
+		if err := os.WriteFile(tmpPath, config.Annotate(edited, issues), 0o600); err != nil {
+			return fmt.Errorf("failed to write temporary file: %w", err)
+		}
+	}
+}
+
+// replaceConfig renames the edited copy at tmpPath over the config at path,
+// unless the config no longer holds original. The copy is then kept under a
+// name of its own so that the edits are not lost.
+func replaceConfig(path, tmpPath string, original []byte) error {
+	current, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read config: %w", err)
+	}
+	if !bytes.Equal(current, original) {
+		kept := path + ".edited"
+		if err := os.Rename(tmpPath, kept); err != nil {
+			return fmt.Errorf("failed to keep edited copy: %w", err)
+		}
+		return fmt.Errorf("the config was changed by another command while it was being edited; your version was saved to %s", kept)
+	}
+
+	if err := os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("failed to replace config: %w", err)
+	}
+	fmt.Printf("Config saved to: %s\n", path)
+	return nil
+}
+
+// validateOptions checks key files in the SSH directory and resolves
+// references against the overlays the config in data is merged over.
+func validateOptions(data []byte) config.ValidateOptions {
+	opts := config.ValidateOptions{SSHDir: ssh.KeyDir()}
+
+	var head struct {
+		Includes []string `yaml:"includes"`
+	}
+	_ = yaml.Unmarshal(data, &head)
+
+	base, err := config.LoadOverlays(head.Includes)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		return opts
+	}
+	opts.Base = base
+	return opts
+}
+
+// runEditor opens path in the user's editor and waits for it to exit.
+func runEditor(path string) error {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+		if runtime.GOOS == "windows" {
+			editor = "notepad"
+		}
+	}
+
+	// Editors are often configured with arguments, e.g. "code --wait".
+	fields := strings.Fields(editor)
+	c := exec.Command(fields[0], append(fields[1:], path)...)
+	c.Stdin = os.Stdin
+	c.Stdout = os.Stdout
+	c.Stderr = os.Stderr
+	if err := c.Run(); err != nil {
+		return fmt.Errorf("editor '%s' failed: %w", editor, err)
+	}
+	return nil
+}
+
+type sourceView struct {
+	Path     string   `json:"path" yaml:"path"`
+	ReadOnly bool     `json:"read_only" yaml:"read_only"`
+	Missing  bool     `json:"missing,omitempty" yaml:"missing,omitempty"`
+	Accounts []string `json:"accounts" yaml:"accounts"`
+	Profiles []string `json:"profiles,omitempty" yaml:"profiles,omitempty"`
+}
+
+type originView struct {
+	Name      string   `json:"name" yaml:"name"`
+	Source    string   `json:"source" yaml:"source"`
+	Overrides []string `json:"overrides,omitempty" yaml:"overrides,omitempty"`
+}
+
+type sourcesView struct {
+	Sources  []sourceView `json:"sources" yaml:"sources"`
+	Accounts []originView `json:"accounts" yaml:"accounts"`
+	Profiles []originView `json:"profiles,omitempty" yaml:"profiles,omitempty"`
+}
+
+func runConfigSources(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	view := sourcesView{Sources: []sourceView{}, Accounts: []originView{}}
+	for _, src := range cfg.Sources() {
+		accounts := src.Accounts
+		if accounts == nil {
+			accounts = []string{}
+		}
+		view.Sources = append(view.Sources, sourceView{
+			Path:     src.Path,
+			ReadOnly: src.ReadOnly,
+			Missing:  src.Missing,
+			Accounts: accounts,
+			Profiles: src.Profiles,
+		})
+	}
+	view.Accounts = origins(view.Sources, func(s sourceView) []string { return s.Accounts })
+	view.Profiles = origins(view.Sources, func(s sourceView) []string { return s.Profiles })
+
+	if structuredOutput() {
+		return printStructured(view)
+	}
+
+	fmt.Println("Config files, highest precedence first:")
+	for _, src := range view.Sources {
+		var notes []string
+		if src.ReadOnly {
+			notes = append(notes, "read-only")
+		}
+		if src.Missing {
+			notes = append(notes, "missing")
+		}
+		if len(notes) > 0 {
+			fmt.Printf("  %s (%s)\n", src.Path, strings.Join(notes, ", "))
+		} else {
+			fmt.Printf("  %s\n", src.Path)
+		}
+	}
+
+	printOrigins("Accounts", view.Accounts)
+	printOrigins("Profiles", view.Profiles)
+	return nil
+}
+
+// origins attributes each name to the first source defining it.
+func origins(sources []sourceView, names func(sourceView) []string) []originView {
+	var result []originView
+	index := make(map[string]int)
+	for _, src := range sources {
+		for _, name := range names(src) {
+			if i, ok := index[name]; ok {
+				result[i].Overrides = append(result[i].Overrides, src.Path)
+				continue
+			}
+			index[name] = len(result)
+			result = append(result, originView{Name: name, Source: src.Path})
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
+}
+
+func printOrigins(title string, origins []originView) {
+	if len(origins) == 0 {
+		return
+	}
+	fmt.Printf("\n%s:\n", title)
+	for _, o := range origins {
+		if len(o.Overrides) > 0 {
+			fmt.Printf("  %-16s %s (overrides %s)\n", o.Name, o.Source, strings.Join(o.Overrides, ", "))
+		} else {
+			fmt.Printf("  %-16s %s\n", o.Name, o.Source)
+		}
+	}
+}
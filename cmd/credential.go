@@ -0,0 +1,194 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/naxodev/github-switch/internal/config"
+	"github.com/naxodev/github-switch/internal/credential"
+	"github.com/naxodev/github-switch/internal/project"
+	"github.com/naxodev/github-switch/internal/state"
+	"github.com/spf13/cobra"
+)
+
+var credentialCmd = &cobra.Command{
+	Use:   "credential <get|store|erase>",
+	Short: "Git credential helper serving per-account tokens",
+	Long: `Act as a Git credential helper for HTTPS remotes, answering with the
+personal access token of the account the request belongs to.
+
+The account is chosen from, in order: the account given to 'exec' or 'env'
+through ` + envAccount + `, a remote rule matching the requested URL
+(requires credential.useHttpPath), an account whose user matches the
+requested username, the account the working directory is bound to, and the
+active account. Only accounts on the requested host are considered, and
+store and erase leave alone an account whose user differs from the
+requested username.
+
+Tokens are kept in the encrypted store managed by 'github-switch token'.
+
+Enable it with:
+
+  git config --global credential.helper '!github-switch credential'
+  git config --global credential.useHttpPath true`,
+	Args:      cobra.ExactArgs(1),
+	ValidArgs: []string{"get", "store", "erase"},
+	// Errors end up on Git's stderr, where usage text is only noise.
+	SilenceUsage: true,
+	RunE:         runCredential,
+}
+
+func init() {
+	rootCmd.AddCommand(credentialCmd)
+}
+
+func runCredential(cmd *cobra.Command, args []string) error {
+	operation := args[0]
+	if operation != "get" && operation != "store" && operation != "erase" {
+		return fmt.Errorf("unknown credential operation '%s'", operation)
+	}
+
+	req, err := credential.Read(os.Stdin)
+	if err != nil {
+		return err
+	}
+	if req.Protocol != "https" {
+		return nil
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	name, err := credentialAccount(cfg, req)
+	if err != nil || name == "" {
+		return err
+	}
+
+	if operation != "store" && !hasSecrets(cfg) {
+		return nil
+	}
+
+	// Git runs get on every fetch and push, so it reads without the lock.
+	if operation == "get" {
+		store, err := readSecrets(cfg)
+		if err != nil {
+			return err
+		}
+		token, ok := store.Get(name)
+		if !ok {
+			return nil
+		}
+		acc, _ := cfg.GetAccount(name)
+		username := acc.User
+		if username == "" {
+			username = req.Username
+		}
+		if username == "" {
+			username = "x-access-token"
+		}
+		return credential.Write(os.Stdout, username, token)
+	}
+
+	key, err := secretKey()
+	if err != nil {
+		return err
+	}
+
+	return locked(func() error {
+		// Reload under the lock, since store may save the config.
+		cfg, err := config.Load()
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+		acc, ok := cfg.GetAccount(name)
+		if !ok {
+			return nil
+		}
+		// A fallback such as the active account may answer for another user,
+		// whose credentials are not this account's to change.
+		if acc.User != "" && req.Username != acc.User {
+			return nil
+		}
+
+		store, err := openStore(cfg, key)
+		if err != nil {
+			return err
+		}
+		token, hasToken := store.Get(name)
+
+		switch operation {
+		case "store":
+			if req.Password == "" || req.Password == token {
+				return nil
+			}
+			store.Set(name, req.Password)
+			if acc.User == "" && req.Username != "" {
+				// Only the user is written, so inherited fields stay inherited.
+				raw := cfg.Accounts[name]
+				raw.User = req.Username
+				cfg.AddAccount(name, raw)
+				if err := cfg.Save(); err != nil {
+					return fmt.Errorf("failed to save config: %w", err)
+				}
+			}
+
+		case "erase":
+			if !hasToken || (req.Password != "" && req.Password != token) {
+				return nil
+			}
+			store.Delete(name)
+		}
+
+		return store.Save()
+	})
+}
+
+// credentialAccount picks the account whose token answers req, or returns
+// an empty name when none applies.
+func credentialAccount(cfg *config.Config, req *credential.Request) (string, error) {
+	var candidates []string
+
+	// Set by exec and env, so that one process or shell pushes as its account.
+	if name := os.Getenv(envAccount); name != "" {
+		candidates = append(candidates, name)
+	}
+
+	if req.Path != "" {
+		if rule, ok := project.MatchRemote(cfg.Remotes, req.URL()); ok {
+			candidates = append(candidates, rule.Account)
+		}
+	}
+
+	if req.Username != "" {
+		for _, name := range cfg.ListAccounts() {
+			if acc, _ := cfg.GetAccount(name); acc.User == req.Username {
+				candidates = append(candidates, name)
+			}
+		}
+	}
+
+	binding, err := project.Resolve(cfg, ".")
+	if err != nil {
+		return "", err
+	}
+	if binding != nil {
+		candidates = append(candidates, binding.Account)
+	}
+
+	st, err := state.Load()
+	if err != nil {
+		return "", fmt.Errorf("failed to load state: %w", err)
+	}
+	if st.Account != "" {
+		candidates = append(candidates, st.Account)
+	}
+
+	for _, name := range candidates {
+		if acc, ok := cfg.GetAccount(name); ok && acc.Hostname() == req.Host {
+			return name, nil
+		}
+	}
+	return "", nil
+}
@@ -2,23 +2,71 @@ package cmd
 
 import (
 	"fmt"
+	"os"
+	"strings"
+	"time"
 
 	"github.com/naxodev/github-switch/internal/config"
+	"github.com/naxodev/github-switch/internal/gh"
 	"github.com/naxodev/github-switch/internal/git"
+	"github.com/naxodev/github-switch/internal/project"
 	"github.com/naxodev/github-switch/internal/ssh"
+	"github.com/naxodev/github-switch/internal/state"
 	"github.com/spf13/cobra"
 )
 
 var currentCmd = &cobra.Command{
 	Use:   "current",
 	Short: "Show the current GitHub account",
-	RunE:  runCurrent,
+	Long: `Show the account last activated with 'github-switch switch' and check
+that the SSH config, global Git identity, ssh-agent and GitHub CLI user
+still match it.
+Inside a directory bound to an account by a .github-switch marker file or
+a directory rule, also check that the identity in use is permitted there.
+
+Exits with a non-zero status when any of them has drifted.`,
+	Annotations: map[string]string{outputAnnotation: "true"},
+	RunE:        runCurrent,
 }
 
 func init() {
 	rootCmd.AddCommand(currentCmd)
 }
 
+const (
+	checkOK      = "ok"
+	checkDrift   = "drift"
+	checkUnknown = "unknown"
+)
+
+// fieldCheck compares one piece of live configuration against the value the
+// active account expects.
+type fieldCheck struct {
+	Field    string `json:"field" yaml:"field"`
+	Expected string `json:"expected" yaml:"expected"`
+	Actual   string `json:"actual" yaml:"actual"`
+	Status   string `json:"status" yaml:"status"`
+}
+
+type currentView struct {
+	Account    string       `json:"account" yaml:"account"`
+	SwitchedAt *time.Time   `json:"switched_at,omitempty" yaml:"switched_at,omitempty"`
+	Name       string       `json:"name" yaml:"name"`
+	Email      string       `json:"email" yaml:"email"`
+	SSHKey     string       `json:"ssh_key" yaml:"ssh_key"`
+	Checks     []fieldCheck `json:"checks" yaml:"checks"`
+	Drifted    bool         `json:"drifted" yaml:"drifted"`
+}
+
+var checkLabels = map[string]string{
+	"ssh_key":   "SSH Key",
+	"git_name":  "Git Name",
+	"git_email": "Git Email",
+	"ssh_agent": "ssh-agent",
+	"gh_user":   "gh User",
+	"project":   "Project",
+}
+
 func runCurrent(cmd *cobra.Command, args []string) error {
 	name, email, err := git.GetCurrentUser()
 	if err != nil {
@@ -35,24 +83,190 @@ func runCurrent(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to load config: %w", err)
 	}
 
-	var matchedAccount string
-	for accName, acc := range cfg.Accounts {
-		if acc.SSHKey == currentKey || acc.Email == email {
-			matchedAccount = accName
-			break
+	st, err := state.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load state: %w", err)
+	}
+
+	view := currentView{
+		Account: st.Account,
+		Name:    name,
+		Email:   email,
+		SSHKey:  currentKey,
+		Checks:  []fieldCheck{},
+	}
+
+	if st.Account != "" {
+		account, ok := cfg.GetAccount(st.Account)
+		if !ok {
+			fmt.Fprintf(os.Stderr, "Active account '%s' is no longer configured.\n", st.Account)
+			return exitWithCode(cmd, 1)
+		}
+
+		switchedAt := st.SwitchedAt
+		view.SwitchedAt = &switchedAt
+		view.Checks = []fieldCheck{
+			compareField("ssh_key", account.SSHKey, currentKey),
+			compareField("git_name", account.Name, name),
+			compareField("git_email", account.Email, email),
+			agentCheck(account.SSHKey),
+		}
+		if check := ghCheck(account); check != nil {
+			view.Checks = append(view.Checks, *check)
 		}
 	}
 
-	fmt.Println("Current configuration:")
-	fmt.Printf("  Name:    %s\n", name)
-	fmt.Printf("  Email:   %s\n", email)
-	fmt.Printf("  SSH Key: %s\n", currentKey)
+	projectCheck, err := checkProject(cfg, st.Account)
+	if err != nil {
+		return err
+	}
+	if projectCheck != nil {
+		view.Checks = append(view.Checks, *projectCheck)
+	}
 
-	if matchedAccount != "" {
-		fmt.Printf("\nMatched account: %s\n", matchedAccount)
+	for _, c := range view.Checks {
+		if c.Status == checkDrift {
+			view.Drifted = true
+		}
+	}
+
+	if structuredOutput() {
+		if err := printStructured(view); err != nil {
+			return err
+		}
 	} else {
-		fmt.Println("\nNo matching account found in configuration.")
+		printCurrent(view)
 	}
 
+	if view.Drifted {
+		return exitWithCode(cmd, 1)
+	}
 	return nil
 }
+
+func printCurrent(view currentView) {
+	if view.Account == "" {
+		fmt.Println("Current configuration:")
+		fmt.Printf("  Name:    %s\n", view.Name)
+		fmt.Printf("  Email:   %s\n", view.Email)
+		fmt.Printf("  SSH Key: %s\n", view.SSHKey)
+		fmt.Println("\nNo active account recorded. Use 'github-switch switch' to activate one.")
+		if len(view.Checks) > 0 {
+			fmt.Println()
+		}
+	} else {
+		fmt.Printf("Active account: %s (switched %s)\n\n", view.Account, view.SwitchedAt.Local().Format("2006-01-02 15:04"))
+	}
+
+	for _, c := range view.Checks {
+		status := c.Status
+		if c.Status == checkDrift {
+			status = fmt.Sprintf("drift (expected %s)", c.Expected)
+		}
+		fmt.Printf("  %-10s %-30s %s\n", checkLabels[c.Field]+":", c.Actual, status)
+	}
+
+	accountDrifted := false
+	for _, c := range view.Checks {
+		if c.Status != checkDrift {
+			continue
+		}
+		if c.Field == "project" {
+			fmt.Printf("\nThis directory expects account %s. Run 'github-switch apply' to use it in this repository.\n", c.Expected)
+		} else {
+			accountDrifted = true
+		}
+	}
+	if accountDrifted {
+		fmt.Printf("\nConfiguration has drifted from account '%s'. Run 'github-switch switch %s' to re-apply it.\n", view.Account, view.Account)
+	}
+}
+
+func compareField(field, expected, actual string) fieldCheck {
+	status := checkOK
+	if expected != actual {
+		status = checkDrift
+	}
+	return fieldCheck{Field: field, Expected: expected, Actual: actual, Status: status}
+}
+
+func agentCheck(sshKey string) fieldCheck {
+	check := fieldCheck{Field: "ssh_agent", Expected: "loaded"}
+
+	loaded, err := ssh.KeyInAgent(sshKey)
+	switch {
+	case err != nil:
+		check.Actual = "unavailable"
+		check.Status = checkUnknown
+	case loaded:
+		check.Actual = "loaded"
+		check.Status = checkOK
+	default:
+		check.Actual = "not loaded"
+		check.Status = checkDrift
+	}
+
+	return check
+}
+
+// ghCheck compares the GitHub CLI's active user with the account's user. It
+// returns nil when the account has no user or gh is not logged in to its host.
+func ghCheck(account config.Account) *fieldCheck {
+	if account.User == "" {
+		return nil
+	}
+	if loggedIn, _ := gh.LoggedIn(account.Hostname()); !loggedIn {
+		return nil
+	}
+
+	user, err := gh.ActiveUser(account.Hostname())
+	if err != nil {
+		return &fieldCheck{Field: "gh_user", Expected: account.User, Actual: "unavailable", Status: checkUnknown}
+	}
+	check := compareField("gh_user", account.User, user)
+	return &check
+}
+
+// checkProject verifies the identity in use matches the account the current
+// directory is bound to. The repository-local Git email is honoured, so a
+// repository configured with 'github-switch apply' passes even when the
+// global account differs. It returns nil when the directory is not bound.
+func checkProject(cfg *config.Config, active string) (*fieldCheck, error) {
+	binding, err := project.Resolve(cfg, ".")
+	if err != nil {
+		return nil, err
+	}
+	if binding == nil {
+		return nil, nil
+	}
+
+	check := &fieldCheck{
+		Field:    "project",
+		Expected: strings.Join(binding.Accounts(), " or "),
+		Actual:   active,
+		Status:   checkOK,
+	}
+	if binding.Permits(active) {
+		return check, nil
+	}
+
+	repo, err := git.RepoRoot(".")
+	if err != nil {
+		return nil, err
+	}
+	if repo != "" {
+		localEmail, err := git.GetLocalConfig(repo, "user.email")
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range binding.Accounts() {
+			if acc, ok := cfg.GetAccount(name); ok && localEmail != "" && acc.Email == localEmail {
+				check.Actual = name + " (repository config)"
+				return check, nil
+			}
+		}
+	}
+
+	check.Status = checkDrift
+	return check, nil
+}
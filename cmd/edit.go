@@ -0,0 +1,295 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/naxodev/github-switch/internal/config"
+	"github.com/naxodev/github-switch/internal/secret"
+	"github.com/naxodev/github-switch/internal/state"
+	"github.com/spf13/cobra"
+)
+
+var (
+	editName    string
+	editEmail   string
+	editSSHKey  string
+	editUser    string
+	editHost    string
+	editExtends string
+	editTags    []string
+	editRename  string
+)
+
+var editCmd = &cobra.Command{
+	Use:   "edit <account-name>",
+	Short: "Modify an existing GitHub account",
+	Long: `Modify an existing GitHub account configuration.
+
+Fields given as flags are changed and the rest are kept. Without any flags
+each field is prompted for, showing its current value; press Enter to keep
+it. Fields inherited through extends are shown with their inherited value
+and only written to the account when changed. --rename also updates the
+directory and remote rules, accounts extending this one, the active account
+and any stored token.
+
+If the account is active, or the active account inherits from it, the
+changes are applied to SSH and Git right away.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runEdit,
+}
+
+func init() {
+	editCmd.Flags().StringVarP(&editName, "name", "n", "", "Git user name")
+	editCmd.Flags().StringVarP(&editEmail, "email", "e", "", "Git email address")
+	editCmd.Flags().StringVarP(&editSSHKey, "ssh-key", "k", "", "SSH key filename (in the SSH directory)")
+	editCmd.Flags().StringVarP(&editUser, "user", "u", "", "GitHub username, used for HTTPS credentials")
+	editCmd.Flags().StringVar(&editHost, "host", "", "GitHub host (empty for github.com)")
+	editCmd.Flags().StringVar(&editExtends, "extends", "", "Profile or account to inherit from (empty to stop inheriting)")
+	editCmd.Flags().StringSliceVarP(&editTags, "tag", "t", nil, "Replace the account's own tags (repeatable; --tag= clears them)")
+	editCmd.Flags().StringVar(&editRename, "rename", "", "New name for the account")
+	rootCmd.AddCommand(editCmd)
+}
+
+func runEdit(cmd *cobra.Command, args []string) error {
+	accountName := args[0]
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	resolved, exists := cfg.GetAccount(accountName)
+	if !exists {
+		return fmt.Errorf("account '%s' not found", accountName)
+	}
+
+	newName := accountName
+	if editRename != "" && editRename != accountName {
+		newName = editRename
+		if cfg.ReadOnly(accountName) {
+			return fmt.Errorf("account '%s' is defined in read-only %s and cannot be renamed", accountName, cfg.Definitions(accountName)[0])
+		}
+	}
+
+	flags := cmd.Flags()
+	edited := false
+	for _, name := range []string{"name", "email", "ssh-key", "user", "host", "extends", "tag", "rename"} {
+		edited = edited || flags.Changed(name)
+	}
+
+	answers := resolved
+	if !edited {
+		if err := promptAccount(&answers); err != nil {
+			return err
+		}
+	}
+
+	// The stored token is keyed by account name, so the passphrase is asked
+	// for before anything is written in case it turns out to be wrong.
+	var key secret.Key
+	if newName != accountName && secret.Exists() {
+		if key, err = secretKey(); err != nil {
+			return err
+		}
+	}
+
+	return locked(func() error {
+		// The config may have changed while the prompts were open.
+		cfg, err := config.Load()
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+		if _, exists := cfg.GetAccount(accountName); !exists {
+			return fmt.Errorf("account '%s' not found", accountName)
+		}
+		// Edits apply to the fields as written, so inherited ones stay inherited.
+		account := cfg.Accounts[accountName]
+
+		st, err := state.Load()
+		if err != nil {
+			return fmt.Errorf("failed to load state: %w", err)
+		}
+		// The active account may inherit from the one being edited.
+		activeBefore, _ := cfg.GetAccount(st.Account)
+
+		if !edited {
+			keepChanges(&account, resolved, answers)
+		} else {
+			if flags.Changed("name") {
+				account.Name = editName
+			}
+			if flags.Changed("email") {
+				account.Email = editEmail
+			}
+			if flags.Changed("ssh-key") {
+				account.SSHKey = editSSHKey
+			}
+			if flags.Changed("user") {
+				account.User = editUser
+			}
+			if flags.Changed("host") {
+				account.Host = editHost
+			}
+			if flags.Changed("extends") {
+				account.Extends = editExtends
+			}
+			if flags.Changed("tag") {
+				account.Tags = splitTags(strings.Join(editTags, ","))
+			}
+		}
+
+		var store *secret.Store
+		if newName != accountName && secret.Exists() {
+			store, err = openStore(cfg, key)
+			if err != nil {
+				return err
+			}
+		}
+
+		cfg.AddAccount(accountName, account)
+		if err := cfg.CheckExtends(); err != nil {
+			return err
+		}
+		if resolved, _ := cfg.GetAccount(accountName); resolved.Name == "" || resolved.Email == "" || resolved.SSHKey == "" {
+			return fmt.Errorf("name, email and SSH key are required")
+		}
+
+		if newName != accountName {
+			if err := cfg.RenameAccount(accountName, newName); err != nil {
+				return err
+			}
+		}
+
+		if err := cfg.Save(); err != nil {
+			return fmt.Errorf("failed to save config: %w", err)
+		}
+
+		if store != nil {
+			if token, ok := store.Get(accountName); ok {
+				store.Set(newName, token)
+				store.Delete(accountName)
+				if err := store.Save(); err != nil {
+					return err
+				}
+			}
+		}
+
+		if newName != accountName {
+			fmt.Printf("Account '%s' renamed to '%s'.\n", accountName, newName)
+		} else {
+			fmt.Printf("Account '%s' updated.\n", accountName)
+		}
+
+		if st.Account == accountName {
+			st.Account = newName
+		}
+		activeAfter, ok := cfg.GetAccount(st.Account)
+		if !ok || (st.Account != newName && activeAfter.Equal(activeBefore)) {
+			return nil
+		}
+
+		if err := applyAccount(activeAfter); err != nil {
+			return err
+		}
+		if newName != accountName && st.Account == newName {
+			if err := st.Save(); err != nil {
+				return fmt.Errorf("failed to save state: %w", err)
+			}
+		}
+
+		fmt.Printf("Re-applied active account '%s'.\n", st.Account)
+		return nil
+	})
+}
+
+// keepChanges copies to account the fields that differ between the values
+// shown to the user and their answers.
+func keepChanges(account *config.Account, shown, answers config.Account) {
+	if answers.Name != shown.Name {
+		account.Name = answers.Name
+	}
+	if answers.Email != shown.Email {
+		account.Email = answers.Email
+	}
+	if answers.SSHKey != shown.SSHKey {
+		account.SSHKey = answers.SSHKey
+	}
+	if answers.User != shown.User {
+		account.User = answers.User
+	}
+	if answers.Host != shown.Host {
+		account.Host = answers.Host
+	}
+	if !slices.Equal(answers.Tags, shown.Tags) {
+		account.Tags = answers.Tags
+	}
+}
+
+// promptAccount asks for each field of account, keeping the current value
+// when the answer is empty. Optional fields are cleared with "-".
+func promptAccount(account *config.Account) error {
+	reader := bufio.NewReader(os.Stdin)
+
+	fields := []struct {
+		label    string
+		value    *string
+		optional bool
+	}{
+		{"Git user name", &account.Name, false},
+		{"Git email", &account.Email, false},
+		{"SSH key filename", &account.SSHKey, false},
+		{"GitHub username", &account.User, true},
+		{"GitHub host", &account.Host, true},
+	}
+
+	for _, field := range fields {
+		if field.optional {
+			fmt.Printf("%s [%s] (- to clear): ", field.label, *field.value)
+		} else {
+			fmt.Printf("%s [%s]: ", field.label, *field.value)
+		}
+
+		input, err := reader.ReadString('\n')
+		if err != nil && input == "" {
+			return fmt.Errorf("failed to read input: %w", err)
+		}
+
+		input = strings.TrimSpace(input)
+		switch {
+		case input == "":
+		case input == "-" && field.optional:
+			*field.value = ""
+		default:
+			*field.value = input
+		}
+	}
+
+	fmt.Printf("Tags, comma-separated [%s] (- to clear): ", strings.Join(account.Tags, ", "))
+	input, err := reader.ReadString('\n')
+	if err != nil && input == "" {
+		return fmt.Errorf("failed to read input: %w", err)
+	}
+	switch input = strings.TrimSpace(input); input {
+	case "":
+	case "-":
+		account.Tags = nil
+	default:
+		account.Tags = splitTags(input)
+	}
+	return nil
+}
+
+// splitTags splits a comma-separated list of tags, dropping empty ones.
+func splitTags(s string) []string {
+	var tags []string
+	for _, tag := range strings.Split(s, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
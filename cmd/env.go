@@ -0,0 +1,124 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/naxodev/github-switch/internal/config"
+	"github.com/naxodev/github-switch/internal/shell"
+	"github.com/naxodev/github-switch/internal/ssh"
+	"github.com/spf13/cobra"
+)
+
+// envAccount names the account a shell or process was given through env or
+// exec. It takes precedence over the globally active account in prompt.
+const envAccount = "GITHUB_SWITCH_ACCOUNT"
+
+// identityEnv lists the variables accountEnv sets.
+var identityEnv = []string{
+	envAccount,
+	"GIT_AUTHOR_NAME",
+	"GIT_AUTHOR_EMAIL",
+	"GIT_COMMITTER_NAME",
+	"GIT_COMMITTER_EMAIL",
+	"GIT_SSH_COMMAND",
+}
+
+var (
+	envShell string
+	unsetEnv bool
+)
+
+var envCmd = &cobra.Command{
+	Use:   "env [account]",
+	Short: "Print commands that use an account in the current shell only",
+	Long: `Print environment variable assignments that make Git commit and push as
+an account in the current shell, without touching the global SSH or Git
+config:
+
+  eval "$(github-switch env work)"                        # bash, zsh
+  github-switch env work | source                         # fish
+  github-switch env work | Out-String | Invoke-Expression # PowerShell
+
+The shell is detected from $SHELL unless --shell is given. Use --unset to
+print commands that remove the variables again.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runEnv,
+}
+
+func init() {
+	envCmd.Flags().StringVarP(&envShell, "shell", "s", "", "Shell syntax: "+strings.Join(shell.ExportShells, ", "))
+	envCmd.Flags().BoolVarP(&unsetEnv, "unset", "u", false, "Print commands that unset the variables")
+	rootCmd.AddCommand(envCmd)
+}
+
+func runEnv(cmd *cobra.Command, args []string) error {
+	shellName := envShell
+	if shellName == "" {
+		shellName = detectShell()
+	}
+
+	if unsetEnv {
+		out, err := shell.Unset(shellName, identityEnv)
+		if err != nil {
+			return err
+		}
+		fmt.Print(out)
+		return nil
+	}
+
+	if len(args) == 0 {
+		return fmt.Errorf("an account is required unless --unset is given")
+	}
+	accountName := args[0]
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	account, ok := cfg.GetAccount(accountName)
+	if !ok {
+		return fmt.Errorf("unknown account: %s", accountName)
+	}
+
+	out, err := shell.Export(shellName, accountEnv(accountName, account))
+	if err != nil {
+		return err
+	}
+	fmt.Print(out)
+	return nil
+}
+
+// accountEnv returns the environment that makes Git act as account without
+// relying on global configuration.
+func accountEnv(name string, account config.Account) []shell.Var {
+	return []shell.Var{
+		{Name: envAccount, Value: name},
+		{Name: "GIT_AUTHOR_NAME", Value: account.Name},
+		{Name: "GIT_AUTHOR_EMAIL", Value: account.Email},
+		{Name: "GIT_COMMITTER_NAME", Value: account.Name},
+		{Name: "GIT_COMMITTER_EMAIL", Value: account.Email},
+		{Name: "GIT_SSH_COMMAND", Value: ssh.Command(account.SSHKey)},
+	}
+}
+
+func detectShell() string {
+	if runtime.GOOS == "windows" {
+		return "powershell"
+	}
+
+	name := filepath.Base(os.Getenv("SHELL"))
+	for _, supported := range shell.ExportShells {
+		if name == supported {
+			return name
+		}
+	}
+	if name == "pwsh" {
+		return "powershell"
+	}
+	return "bash"
+}
@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+
+	"github.com/naxodev/github-switch/internal/config"
+	"github.com/spf13/cobra"
+)
+
+var execCmd = &cobra.Command{
+	Use:   "exec <account> -- <command> [args...]",
+	Short: "Run a single command as an account",
+	Long: `Run a command with the identity of an account, set through environment
+variables and GIT_SSH_COMMAND only. Global SSH and Git configuration is not
+touched, so several exec invocations for different accounts can run at the
+same time.
+
+The command's exit status is passed through.
+
+  github-switch exec work -- git push origin main`,
+	Args: cobra.MinimumNArgs(2),
+	RunE: runExec,
+}
+
+func init() {
+	execCmd.Flags().SetInterspersed(false)
+	rootCmd.AddCommand(execCmd)
+}
+
+func runExec(cmd *cobra.Command, args []string) error {
+	accountName := args[0]
+	command := args[1:]
+	if command[0] == "--" {
+		command = command[1:]
+	}
+	if len(command) == 0 {
+		return fmt.Errorf("no command given")
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	account, ok := cfg.GetAccount(accountName)
+	if !ok {
+		return fmt.Errorf("unknown account: %s", accountName)
+	}
+
+	vars := accountEnv(accountName, account)
+
+	child := exec.Command(command[0], command[1:]...)
+	child.Stdin = os.Stdin
+	child.Stdout = os.Stdout
+	child.Stderr = os.Stderr
+	child.Env = os.Environ()
+	for _, v := range vars {
+		child.Env = append(child.Env, v.Name+"="+v.Value)
+	}
+
+	// The child shares our terminal and receives interrupts itself; wait for
+	// it to exit rather than dying first.
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt)
+	defer signal.Stop(signals)
+
+	if err := child.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			code := exitErr.ExitCode()
+			if code < 0 {
+				code = 1
+			}
+			return exitWithCode(cmd, code)
+		}
+		if errors.Is(err, exec.ErrNotFound) {
+			fmt.Fprintf(os.Stderr, "github-switch: %s: command not found\n", command[0])
+			return exitWithCode(cmd, 127)
+		}
+		return fmt.Errorf("failed to run %s: %w", command[0], err)
+	}
+
+	return nil
+}
@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/naxodev/github-switch/internal/bundle"
+	"github.com/naxodev/github-switch/internal/config"
+	"github.com/spf13/cobra"
+)
+
+var (
+	exportFile        string
+	exportPublicKeys  bool
+	exportKeyTemplate string
+	exportTags        []string
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export [account...]",
+	Short: "Export accounts as a portable bundle",
+	Long: `Write the given accounts, or all of them, as a bundle that 'import --file'
+reads on another machine. --tag keeps only accounts carrying every given
+tag. Bundles are YAML, or JSON with --output json or a .json file name.
+Inherited fields are written out in full, so the bundle does not depend on
+profiles. Tokens are never exported.
+
+--public-keys embeds each key's public half, so the importing machine can
+find the matching key whatever it is called there. --key-template replaces
+the key file names with a template such as id_{{.Account}}_ed25519. .Key,
+the key's name on this machine, is filled in right away; .Account, .Host,
+.User and .Email are expanded per account on import.`,
+	Annotations: map[string]string{outputAnnotation: "true"},
+	RunE:        runExport,
+}
+
+func init() {
+	exportCmd.Flags().StringVarP(&exportFile, "file", "f", "", "Write the bundle to a file instead of stdout")
+	exportCmd.Flags().BoolVar(&exportPublicKeys, "public-keys", false, "Embed each account's public key")
+	exportCmd.Flags().StringVar(&exportKeyTemplate, "key-template", "", "Template for the key file names in the bundle")
+	exportCmd.Flags().StringSliceVarP(&exportTags, "tag", "t", nil, "Only export accounts with this tag (repeatable)")
+	rootCmd.AddCommand(exportCmd)
+}
+
+func runExport(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	names := args
+	if len(exportTags) > 0 {
+		tagged := cfg.FilterAccounts(exportTags)
+		if len(args) > 0 {
+			for _, name := range args {
+				if _, ok := cfg.GetAccount(name); !ok {
+					return fmt.Errorf("account '%s' not found", name)
+				}
+			}
+			tagged = slices.DeleteFunc(tagged, func(name string) bool {
+				return !slices.Contains(args, name)
+			})
+		}
+		if len(tagged) == 0 {
+			return fmt.Errorf("no accounts tagged %s", strings.Join(exportTags, ", "))
+		}
+		names = tagged
+	}
+
+	b, err := bundle.FromConfig(cfg, names, bundle.ExportOptions{
+		PublicKeys:  exportPublicKeys,
+		KeyTemplate: exportKeyTemplate,
+	})
+	if err != nil {
+		return err
+	}
+
+	format := outputYAML
+	if outputFormat == outputJSON || strings.EqualFold(filepath.Ext(exportFile), ".json") {
+		format = outputJSON
+	}
+	data, err := b.Marshal(format)
+	if err != nil {
+		return err
+	}
+
+	if exportFile == "" {
+		_, err := os.Stdout.Write(data)
+		return err
+	}
+
+	if err := os.WriteFile(exportFile, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write bundle: %w", err)
+	}
+	fmt.Fprintf(os.Stderr, "Exported %d account(s) to %s\n", len(b.Accounts), exportFile)
+	return nil
+}
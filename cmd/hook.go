@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/naxodev/github-switch/internal/shell"
+	"github.com/spf13/cobra"
+)
+
+var hookCmd = &cobra.Command{
+	Use:   "hook <shell>",
+	Short: "Print a shell hook that applies bound accounts on cd",
+	Long: `Print a snippet that runs 'github-switch apply' whenever the working
+directory changes, so repositories bound to an account through a
+.github-switch marker file or a directory rule pick it up automatically.
+
+Supported shells: ` + strings.Join(shell.Supported, ", ") + `
+
+  # ~/.bashrc
+  eval "$(github-switch hook bash)"
+
+  # ~/.zshrc
+  eval "$(github-switch hook zsh)"
+
+  # ~/.config/fish/config.fish
+  github-switch hook fish | source`,
+	Args:      cobra.ExactArgs(1),
+	ValidArgs: shell.Supported,
+	RunE:      runHook,
+}
+
+func init() {
+	rootCmd.AddCommand(hookCmd)
+}
+
+func runHook(cmd *cobra.Command, args []string) error {
+	exe, err := os.Executable()
+	if err != nil {
+		exe = "github-switch"
+	}
+
+	snippet, err := shell.Hook(args[0], exe)
+	if err != nil {
+		return err
+	}
+
+	fmt.Print(snippet)
+	return nil
+}
@@ -0,0 +1,241 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/naxodev/github-switch/internal/config"
+	"github.com/naxodev/github-switch/internal/git"
+	"github.com/naxodev/github-switch/internal/hooks"
+	"github.com/naxodev/github-switch/internal/project"
+	"github.com/naxodev/github-switch/internal/ssh"
+	"github.com/naxodev/github-switch/internal/xdg"
+	"github.com/spf13/cobra"
+)
+
+var (
+	globalHooks bool
+	forceHooks  bool
+)
+
+var hooksCmd = &cobra.Command{
+	Use:   "hooks",
+	Short: "Manage Git hooks that block commits with the wrong identity",
+	Long: `Manage pre-commit and pre-push hooks that refuse to commit or push when
+the author email or SSH key does not belong to the account the repository
+is bound to through a .github-switch marker file or a directory rule.
+
+Hooks are installed into the current repository, or for every repository
+through the global core.hooksPath with --global. Global hooks still run a
+repository's own hooks afterwards.`,
+}
+
+var hooksInstallCmd = &cobra.Command{
+	Use:   "install",
+	Short: "Install the identity-checking hooks",
+	Args:  cobra.NoArgs,
+	RunE:  runHooksInstall,
+}
+
+var hooksUninstallCmd = &cobra.Command{
+	Use:   "uninstall",
+	Short: "Remove the identity-checking hooks",
+	Args:  cobra.NoArgs,
+	RunE:  runHooksUninstall,
+}
+
+var hooksVerifyCmd = &cobra.Command{
+	Use:   "verify <stage>",
+	Short: "Check the identity for a hook stage (called by the hooks)",
+	Long: `Check that the identity in use matches the account the current
+repository is bound to. This is what the installed hooks run; 'pre-commit'
+checks the author email and 'pre-push' additionally checks the SSH key.`,
+	Args:      cobra.ExactArgs(1),
+	ValidArgs: hooks.Stages,
+	RunE:      runHooksVerify,
+}
+
+func init() {
+	for _, c := range []*cobra.Command{hooksInstallCmd, hooksUninstallCmd} {
+		c.Flags().BoolVarP(&globalHooks, "global", "g", false, "Use the global core.hooksPath instead of the current repository")
+	}
+	hooksInstallCmd.Flags().BoolVarP(&forceHooks, "force", "f", false, "Replace existing hooks and core.hooksPath settings")
+
+	hooksCmd.AddCommand(hooksInstallCmd, hooksUninstallCmd, hooksVerifyCmd)
+	rootCmd.AddCommand(hooksCmd)
+}
+
+func globalHooksDir() (string, error) {
+	dir, err := xdg.ConfigHome()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, xdg.App, "hooks"), nil
+}
+
+func repoHooksDir() (string, error) {
+	repo, err := git.RepoRoot(".")
+	if err != nil {
+		return "", err
+	}
+	if repo == "" {
+		return "", fmt.Errorf("not inside a Git repository (use --global to install for all repositories)")
+	}
+	return git.HooksDir(repo)
+}
+
+func runHooksInstall(cmd *cobra.Command, args []string) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to locate github-switch executable: %w", err)
+	}
+
+	hooksPath, err := git.GetGlobalConfig("core.hooksPath")
+	if err != nil {
+		return err
+	}
+
+	if !globalHooks {
+		dir, err := repoHooksDir()
+		if err != nil {
+			return err
+		}
+		if err := hooks.Install(dir, exe, forceHooks); err != nil {
+			return err
+		}
+		fmt.Printf("Hooks installed in %s\n", dir)
+		if hooksPath != "" {
+			fmt.Fprintf(os.Stderr, "Warning: global core.hooksPath is set to %s, so Git will not run these hooks.\n", hooksPath)
+		}
+		return nil
+	}
+
+	dir, err := globalHooksDir()
+	if err != nil {
+		return err
+	}
+	if hooksPath != "" && hooksPath != dir && !forceHooks {
+		return fmt.Errorf("core.hooksPath is already set to %s (use --force to replace it)", hooksPath)
+	}
+
+	if err := hooks.Install(dir, exe, forceHooks); err != nil {
+		return err
+	}
+	if err := git.SetGlobalConfig("core.hooksPath", dir); err != nil {
+		return err
+	}
+
+	fmt.Printf("Hooks installed in %s and enabled through core.hooksPath.\n", dir)
+	return nil
+}
+
+func runHooksUninstall(cmd *cobra.Command, args []string) error {
+	if !globalHooks {
+		dir, err := repoHooksDir()
+		if err != nil {
+			return err
+		}
+		if err := hooks.Uninstall(dir); err != nil {
+			return err
+		}
+		fmt.Printf("Hooks removed from %s\n", dir)
+		return nil
+	}
+
+	dir, err := globalHooksDir()
+	if err != nil {
+		return err
+	}
+	if err := hooks.Uninstall(dir); err != nil {
+		return err
+	}
+
+	hooksPath, err := git.GetGlobalConfig("core.hooksPath")
+	if err != nil {
+		return err
+	}
+	if hooksPath == dir {
+		if err := git.UnsetGlobalConfig("core.hooksPath"); err != nil {
+			return err
+		}
+	}
+
+	fmt.Printf("Hooks removed from %s\n", dir)
+	return nil
+}
+
+func runHooksVerify(cmd *cobra.Command, args []string) error {
+	stage := args[0]
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	binding, err := project.Resolve(cfg, ".")
+	if err != nil {
+		return err
+	}
+	if binding == nil {
+		return nil
+	}
+
+	repo, err := git.RepoRoot(".")
+	if err != nil {
+		return err
+	}
+	if repo == "" {
+		return nil
+	}
+
+	email, err := git.AuthorEmail(repo)
+	if err != nil {
+		return err
+	}
+
+	var account config.Account
+	var accountName string
+	for _, name := range binding.Accounts() {
+		if acc, ok := cfg.GetAccount(name); ok && strings.EqualFold(acc.Email, email) {
+			account, accountName = acc, name
+			break
+		}
+	}
+
+	if accountName == "" {
+		fmt.Fprintf(os.Stderr, "github-switch: author email <%s> does not belong to account %s required by %s.\n",
+			email, quoteList(binding.Accounts()), binding.Source)
+		fmt.Fprintln(os.Stderr, "Run 'github-switch apply' to use the right identity in this repository.")
+		return exitWithCode(cmd, 1)
+	}
+
+	if stage == "pre-push" {
+		key := effectiveSSHKey(repo)
+		if key != "" && key != filepath.Base(account.SSHKey) {
+			fmt.Fprintf(os.Stderr, "github-switch: SSH key '%s' does not belong to account '%s' (expected '%s').\n",
+				key, accountName, account.SSHKey)
+			fmt.Fprintln(os.Stderr, "Run 'github-switch apply' to use the right identity in this repository.")
+			return exitWithCode(cmd, 1)
+		}
+	}
+
+	return nil
+}
+
+// effectiveSSHKey returns the key Git will use to reach GitHub from repo:
+// GIT_SSH_COMMAND, then core.sshCommand, then the github.com block of the
+// SSH config. It returns an empty string when the key cannot be determined.
+func effectiveSSHKey(repo string) string {
+	command := os.Getenv("GIT_SSH_COMMAND")
+	if command == "" {
+		command, _ = git.GetConfig(repo, "core.sshCommand")
+	}
+	if key := ssh.KeyFromCommand(command); key != "" {
+		return key
+	}
+
+	key, _ := ssh.GetCurrentKey()
+	return key
+}
@@ -0,0 +1,333 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/naxodev/github-switch/internal/bundle"
+	"github.com/naxodev/github-switch/internal/config"
+	"github.com/naxodev/github-switch/internal/discover"
+	"github.com/naxodev/github-switch/internal/git"
+	"github.com/naxodev/github-switch/internal/ssh"
+	"github.com/spf13/cobra"
+)
+
+var (
+	importYes         bool
+	importDryRun      bool
+	importFile        string
+	importOnConflict  string
+	importKeyTemplate string
+)
+
+var importCmd = &cobra.Command{
+	Use:   "import",
+	Short: "Import accounts from an existing SSH and Git setup",
+	Long: `Propose accounts from an existing hand-rolled setup: GitHub host aliases
+in the SSH config (such as Host github.com-work) with their IdentityFile, and
+the name and email of Git config files pulled in with includeIf. A host and an
+include are paired when the include mentions the host's label, e.g. work.
+
+Each proposal is confirmed and can be renamed or completed interactively.
+Includes with a gitdir: condition also add a directory rule.
+
+With --file, accounts are read from a bundle written by 'export' instead
+("-" reads stdin). Key file names are matched through embedded public keys
+where possible, otherwise taken from the bundle or from --key-template, which
+can use .Account, .Key, .Host, .User and .Email. Accounts that already exist
+are skipped, overwritten or added under a new name as --on-conflict says.`,
+	Args: cobra.NoArgs,
+	RunE: runImport,
+}
+
+func init() {
+	importCmd.Flags().BoolVarP(&importYes, "yes", "y", false, "Add every complete proposal without asking")
+	importCmd.Flags().BoolVar(&importDryRun, "dry-run", false, "Only show the proposals")
+	importCmd.Flags().StringVarP(&importFile, "file", "f", "", "Import a bundle written by 'export'")
+	importCmd.Flags().StringVar(&importOnConflict, "on-conflict", bundle.Skip, "What to do with existing accounts: skip, overwrite or rename")
+	importCmd.Flags().StringVar(&importKeyTemplate, "key-template", "", "Template for the local key file names")
+	rootCmd.AddCommand(importCmd)
+}
+
+func runImport(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	if importFile != "" {
+		return importBundle(cfg)
+	}
+
+	hosts, err := ssh.Hosts()
+	if err != nil {
+		return err
+	}
+	identities, global, err := gitIdentities()
+	if err != nil {
+		return err
+	}
+
+	candidates := discover.Candidates(hosts, identities, global, cfg)
+	if len(candidates) == 0 {
+		fmt.Println("No new accounts found in the SSH or Git config.")
+		return nil
+	}
+
+	reader := bufio.NewReader(os.Stdin)
+	var accepted []acceptedCandidate
+
+	for _, c := range candidates {
+		printCandidate(c)
+		if importDryRun {
+			continue
+		}
+
+		name := c.Label
+		if importYes {
+			if !c.Complete() {
+				fmt.Println("Skipped: name, email and SSH key are all required.")
+				continue
+			}
+		} else {
+			fmt.Printf("Account name [%s] (- to skip): ", c.Label)
+			input, _ := reader.ReadString('\n')
+			input = strings.TrimSpace(input)
+			if input == "-" {
+				continue
+			}
+			if input != "" {
+				name = input
+			}
+			if err := completeCandidate(reader, &c.Account); err != nil {
+				return err
+			}
+		}
+
+		if _, exists := cfg.GetAccount(name); exists {
+			fmt.Printf("Skipped: account '%s' already exists.\n", name)
+			continue
+		}
+		if c.Account.Name == "" || c.Account.Email == "" || c.Account.SSHKey == "" {
+			fmt.Println("Skipped: name, email and SSH key are all required.")
+			continue
+		}
+
+		// Added to the loaded config only so that a later proposal cannot
+		// take the same name; the config is reloaded before writing.
+		cfg.AddAccount(name, c.Account)
+		accepted = append(accepted, acceptedCandidate{name: name, candidate: c})
+	}
+
+	if len(accepted) == 0 {
+		if !importDryRun {
+			fmt.Println("\nNo accounts imported.")
+		}
+		return nil
+	}
+
+	return locked(func() error {
+		// The config may have changed while the prompts were open.
+		cfg, err := config.Load()
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+
+		var added []string
+		for _, a := range accepted {
+			if _, exists := cfg.GetAccount(a.name); exists {
+				fmt.Printf("Skipped: account '%s' already exists.\n", a.name)
+				continue
+			}
+			cfg.AddAccount(a.name, a.candidate.Account)
+			if dir := a.candidate.Directory; dir != "" {
+				if cfg.Directories == nil {
+					cfg.Directories = make(map[string]string)
+				}
+				if _, exists := cfg.Directories[dir]; !exists {
+					cfg.Directories[dir] = a.name
+				}
+			}
+			added = append(added, a.name)
+		}
+		if len(added) == 0 {
+			fmt.Println("\nNo accounts imported.")
+			return nil
+		}
+
+		if err := cfg.Save(); err != nil {
+			return fmt.Errorf("failed to save config: %w", err)
+		}
+
+		fmt.Printf("\nImported %d account(s): %s.\n", len(added), strings.Join(added, ", "))
+		fmt.Printf("Config saved to: %s\n", config.GetConfigPath())
+		return nil
+	})
+}
+
+// acceptedCandidate is a proposal the user accepted under name.
+type acceptedCandidate struct {
+	name      string
+	candidate discover.Candidate
+}
+
+func importBundle(cfg *config.Config) error {
+	var data []byte
+	var err error
+	if importFile == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(importFile)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read bundle: %w", err)
+	}
+
+	b, err := bundle.Parse(data)
+	if err != nil {
+		return err
+	}
+
+	if importDryRun {
+		return mergeBundle(cfg, b)
+	}
+	return locked(func() error {
+		cfg, err := config.Load()
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+		return mergeBundle(cfg, b)
+	})
+}
+
+// mergeBundle adds the accounts of b to cfg, reports what happened to each
+// and saves cfg unless this is a dry run.
+func mergeBundle(cfg *config.Config, b *bundle.Bundle) error {
+	result, err := bundle.Merge(cfg, b, bundle.ImportOptions{
+		OnConflict:  importOnConflict,
+		KeyTemplate: importKeyTemplate,
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, warning := range result.Warnings {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", warning)
+	}
+	for _, name := range result.Added {
+		acc, _ := cfg.GetAccount(name)
+		fmt.Printf("Added '%s' (%s, key %s)\n", name, acc.Email, acc.SSHKey)
+	}
+	for _, name := range result.Overwritten {
+		fmt.Printf("Overwrote '%s'\n", name)
+	}
+	for _, name := range sortedKeys(result.Renamed) {
+		fmt.Printf("Added '%s' as '%s'\n", name, result.Renamed[name])
+	}
+	for _, name := range result.Skipped {
+		fmt.Printf("Skipped '%s': it already exists\n", name)
+	}
+
+	if importDryRun {
+		fmt.Println("Dry run; the config was not modified.")
+		return nil
+	}
+	if len(result.Added)+len(result.Overwritten)+len(result.Renamed) == 0 {
+		return nil
+	}
+
+	if err := cfg.Save(); err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+	fmt.Printf("Config saved to: %s\n", config.GetConfigPath())
+	return nil
+}
+
+// gitIdentities reads the identity of every conditional include of the
+// global Git config, and of the global config itself.
+func gitIdentities() ([]discover.Identity, discover.Identity, error) {
+	var global discover.Identity
+	var err error
+	global.Name, global.Email, err = git.GetCurrentUser()
+	if err != nil {
+		return nil, global, err
+	}
+
+	includes, err := git.Includes()
+	if err != nil {
+		return nil, global, err
+	}
+
+	var identities []discover.Identity
+	for _, include := range includes {
+		id := discover.Identity{Condition: include.Condition, Path: include.Path}
+		if id.Name, err = git.GetFileConfig(include.Path, "user.name"); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: skipping %s: %v\n", include.Path, err)
+			continue
+		}
+		if id.Email, err = git.GetFileConfig(include.Path, "user.email"); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: skipping %s: %v\n", include.Path, err)
+			continue
+		}
+		identities = append(identities, id)
+	}
+	return identities, global, nil
+}
+
+func printCandidate(c discover.Candidate) {
+	fmt.Printf("\nFound account '%s' (from %s):\n", c.Label, strings.Join(c.Sources, ", "))
+	fmt.Printf("  Name:      %s\n", orNone(c.Account.Name))
+	fmt.Printf("  Email:     %s\n", orNone(c.Account.Email))
+	fmt.Printf("  SSH Key:   %s\n", orNone(c.Account.SSHKey))
+	if c.Account.Host != "" {
+		fmt.Printf("  Host:      %s\n", c.Account.Host)
+	}
+	if c.Directory != "" {
+		fmt.Printf("  Directory: %s\n", c.Directory)
+	}
+}
+
+// completeCandidate prompts for the required fields a proposal lacks.
+func completeCandidate(reader *bufio.Reader, account *config.Account) error {
+	fields := []struct {
+		label string
+		value *string
+	}{
+		{"Git user name", &account.Name},
+		{"Git email", &account.Email},
+		{"SSH key filename", &account.SSHKey},
+	}
+
+	for _, field := range fields {
+		if *field.value != "" {
+			continue
+		}
+		fmt.Printf("%s: ", field.label)
+		input, err := reader.ReadString('\n')
+		if err != nil && input == "" {
+			return fmt.Errorf("failed to read input: %w", err)
+		}
+		*field.value = strings.TrimSpace(input)
+	}
+	return nil
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "(none)"
+	}
+	return s
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
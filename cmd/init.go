@@ -12,7 +12,7 @@ var initCmd = &cobra.Command{
 	Short: "Initialize an empty config file",
 	Long: `Initialize an empty configuration file.
 Use 'github-switch add' to add your accounts after initialization.`,
-	RunE: runInit,
+	RunE: withLock(runInit),
 }
 
 func init() {
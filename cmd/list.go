@@ -2,48 +2,108 @@ package cmd
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/naxodev/github-switch/internal/config"
-	"github.com/naxodev/github-switch/internal/ssh"
+	"github.com/naxodev/github-switch/internal/state"
 	"github.com/spf13/cobra"
 )
 
+var listTags []string
+
 var listCmd = &cobra.Command{
-	Use:     "list",
-	Short:   "List all configured accounts",
-	Aliases: []string{"ls"},
-	RunE:    runList,
+	Use:   "list",
+	Short: "List all configured accounts",
+	Long: `List the configured accounts. With --tag, only accounts carrying every
+given tag are listed.`,
+	Annotations: map[string]string{outputAnnotation: "true"},
+	Aliases:     []string{"ls"},
+	RunE:        runList,
 }
 
 func init() {
+	listCmd.Flags().StringSliceVarP(&listTags, "tag", "t", nil, "Only list accounts with this tag (repeatable)")
 	rootCmd.AddCommand(listCmd)
 }
 
+type accountView struct {
+	Account string   `json:"account" yaml:"account"`
+	Name    string   `json:"name" yaml:"name"`
+	Email   string   `json:"email" yaml:"email"`
+	SSHKey  string   `json:"ssh_key" yaml:"ssh_key"`
+	Host    string   `json:"host" yaml:"host"`
+	User    string   `json:"user,omitempty" yaml:"user,omitempty"`
+	Extends string   `json:"extends,omitempty" yaml:"extends,omitempty"`
+	Tags    []string `json:"tags,omitempty" yaml:"tags,omitempty"`
+	Active  bool     `json:"active" yaml:"active"`
+}
+
+type listView struct {
+	Accounts []accountView `json:"accounts" yaml:"accounts"`
+}
+
 func runList(cmd *cobra.Command, args []string) error {
 	cfg, err := config.Load()
 	if err != nil {
 		return fmt.Errorf("failed to load config: %w", err)
 	}
 
-	accounts := cfg.ListAccounts()
-	if len(accounts) == 0 {
+	st, err := state.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load state: %w", err)
+	}
+
+	view := listView{Accounts: []accountView{}}
+	for _, name := range cfg.FilterAccounts(listTags) {
+		acc, _ := cfg.GetAccount(name)
+		view.Accounts = append(view.Accounts, accountView{
+			Account: name,
+			Name:    acc.Name,
+			Email:   acc.Email,
+			SSHKey:  acc.SSHKey,
+			Host:    acc.Hostname(),
+			User:    acc.User,
+			Extends: acc.Extends,
+			Tags:    acc.Tags,
+			Active:  name == st.Account,
+		})
+	}
+
+	if structuredOutput() {
+		return printStructured(view)
+	}
+
+	if len(view.Accounts) == 0 {
+		if len(listTags) > 0 {
+			fmt.Printf("No accounts tagged %s.\n", strings.Join(listTags, ", "))
+			return nil
+		}
 		fmt.Println("No accounts configured. Use 'github-switch add' to add an account.")
 		return nil
 	}
 
-	currentKey, _ := ssh.GetCurrentKey()
-
 	fmt.Println("Configured accounts:")
-	for _, name := range accounts {
-		acc, _ := cfg.GetAccount(name)
+	for _, acc := range view.Accounts {
 		marker := "  "
-		if acc.SSHKey == currentKey {
+		if acc.Active {
 			marker = "* "
 		}
-		fmt.Printf("%s%s\n", marker, name)
+		fmt.Printf("%s%s\n", marker, acc.Account)
 		fmt.Printf("    Email:   %s\n", acc.Email)
 		fmt.Printf("    Name:    %s\n", acc.Name)
 		fmt.Printf("    SSH Key: %s\n", acc.SSHKey)
+		if acc.Host != config.DefaultHost {
+			fmt.Printf("    Host:    %s\n", acc.Host)
+		}
+		if acc.User != "" {
+			fmt.Printf("    User:    %s\n", acc.User)
+		}
+		if acc.Extends != "" {
+			fmt.Printf("    Extends: %s\n", acc.Extends)
+		}
+		if len(acc.Tags) > 0 {
+			fmt.Printf("    Tags:    %s\n", strings.Join(acc.Tags, ", "))
+		}
 	}
 
 	return nil
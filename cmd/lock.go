@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"time"
+
+	"github.com/naxodev/github-switch/internal/config"
+	"github.com/naxodev/github-switch/internal/lock"
+	"github.com/spf13/cobra"
+)
+
+var lockTimeout time.Duration
+
+func init() {
+	rootCmd.PersistentFlags().DurationVar(&lockTimeout, "lock-timeout", 10*time.Second, "How long to wait for another running github-switch")
+}
+
+// withLock makes a command hold the lock file next to the config while it
+// runs, so concurrent invocations cannot interleave their read-modify-write
+// cycles of the config, state, secrets and SSH config.
+func withLock(run func(*cobra.Command, []string) error) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		return locked(func() error {
+			return run(cmd, args)
+		})
+	}
+}
+
+// locked runs fn while holding the lock. Commands that wait for the user,
+// such as switch with its menu, take it only around their writes so that an
+// open prompt does not block other invocations.
+func locked(fn func() error) error {
+	l, err := lock.Acquire(config.GetConfigPath()+".lock", lockTimeout)
+	if err != nil {
+		return err
+	}
+	defer l.Release()
+
+	return fn()
+}
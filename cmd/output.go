@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	outputText = "text"
+	outputJSON = "json"
+	outputYAML = "yaml"
+)
+
+var outputFormat string
+
+func init() {
+	rootCmd.PersistentFlags().StringVarP(&outputFormat, "output", "o", outputText, "Output format: text, json or yaml")
+}
+
+// outputAnnotation marks the commands that honour --output json and yaml.
+// The others refuse them rather than print text a caller would fail to parse.
+const outputAnnotation = "structured-output"
+
+func validateOutputFormat(cmd *cobra.Command) error {
+	switch outputFormat {
+	case outputText:
+		return nil
+	case outputJSON, outputYAML:
+		if cmd.Annotations[outputAnnotation] == "" {
+			return fmt.Errorf("'%s' does not support --output %s", cmd.CommandPath(), outputFormat)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported output format '%s' (use text, json or yaml)", outputFormat)
+	}
+}
+
+// structuredOutput reports whether a machine-readable format was requested.
+func structuredOutput() bool {
+	return outputFormat == outputJSON || outputFormat == outputYAML
+}
+
+// printStructured writes v to stdout in the requested machine-readable format.
+func printStructured(v any) error {
+	switch outputFormat {
+	case outputJSON:
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	case outputYAML:
+		enc := yaml.NewEncoder(os.Stdout)
+		enc.SetIndent(2)
+		if err := enc.Encode(v); err != nil {
+			return err
+		}
+		return enc.Close()
+	default:
+		return fmt.Errorf("unsupported output format '%s'", outputFormat)
+	}
+}
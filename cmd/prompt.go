@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/template"
+
+	"github.com/naxodev/github-switch/internal/config"
+	"github.com/naxodev/github-switch/internal/state"
+	"github.com/spf13/cobra"
+)
+
+var promptFormat string
+
+var promptCmd = &cobra.Command{
+	Use:   "prompt",
+	Short: "Print the active account for use in a shell prompt",
+	Long: `Print the active account name for use in PS1, starship or similar.
+
+Only the state and config files are read, so no git or ssh processes are
+started. An account set for the current shell with 'github-switch env'
+takes precedence over the globally active one. Nothing is printed when no
+account is active.
+
+The format is a Go template with the fields .Account, .Name, .Email and
+.SSHKey, for example:
+
+  github-switch prompt --format '[{{.Account}}] '`,
+	Args: cobra.NoArgs,
+	RunE: runPrompt,
+}
+
+func init() {
+	promptCmd.Flags().StringVarP(&promptFormat, "format", "F", "{{.Account}}", "Go template used to render the prompt")
+	rootCmd.AddCommand(promptCmd)
+}
+
+type promptData struct {
+	Account string
+	Name    string
+	Email   string
+	SSHKey  string
+}
+
+func runPrompt(cmd *cobra.Command, args []string) error {
+	tmpl, err := template.New("prompt").Parse(promptFormat)
+	if err != nil {
+		return fmt.Errorf("invalid prompt format: %w", err)
+	}
+
+	accountName := os.Getenv(envAccount)
+	if accountName == "" {
+		st, err := state.Load()
+		if err != nil {
+			return fmt.Errorf("failed to load state: %w", err)
+		}
+		accountName = st.Account
+	}
+	if accountName == "" {
+		return nil
+	}
+
+	data := promptData{Account: accountName}
+	if cfg, err := config.Load(); err == nil {
+		if acc, ok := cfg.GetAccount(accountName); ok {
+			data.Name = acc.Name
+			data.Email = acc.Email
+			data.SSHKey = acc.SSHKey
+		}
+	}
+
+	return tmpl.Execute(os.Stdout, data)
+}
@@ -7,6 +7,8 @@ import (
 	"strings"
 
 	"github.com/naxodev/github-switch/internal/config"
+	"github.com/naxodev/github-switch/internal/secret"
+	"github.com/naxodev/github-switch/internal/state"
 	"github.com/spf13/cobra"
 )
 
@@ -38,6 +40,10 @@ func runRemove(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("account '%s' not found", accountName)
 	}
 
+	if cfg.ReadOnly(accountName) {
+		return fmt.Errorf("account '%s' is defined in read-only %s", accountName, cfg.Definitions(accountName)[0])
+	}
+
 	if !forceRemove {
 		fmt.Printf("Remove account '%s'?\n", accountName)
 		fmt.Printf("  Name:    %s\n", acc.Name)
@@ -55,12 +61,66 @@ func runRemove(cmd *cobra.Command, args []string) error {
 		}
 	}
 
-	cfg.RemoveAccount(accountName)
-
-	if err := cfg.Save(); err != nil {
-		return fmt.Errorf("failed to save config: %w", err)
+	// The stored token would otherwise go to a later account of the same
+	// name, so the passphrase is asked for before anything is written in
+	// case it turns out to be wrong.
+	var key secret.Key
+	if secret.Exists() {
+		if key, err = secretKey(); err != nil {
+			return err
+		}
 	}
 
-	fmt.Printf("Account '%s' removed.\n", accountName)
-	return nil
+	return locked(func() error {
+		// The config may have changed while the prompts were open.
+		cfg, err := config.Load()
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+		if _, exists := cfg.GetAccount(accountName); !exists {
+			return fmt.Errorf("account '%s' not found", accountName)
+		}
+		if cfg.ReadOnly(accountName) {
+			return fmt.Errorf("account '%s' is defined in read-only %s", accountName, cfg.Definitions(accountName)[0])
+		}
+
+		cfg.RemoveAccount(accountName)
+		if err := cfg.CheckExtends(); err != nil {
+			return fmt.Errorf("cannot remove '%s' while %s extend it", accountName, strings.Join(cfg.ExtendedBy(accountName), ", "))
+		}
+
+		var store *secret.Store
+		if secret.Exists() {
+			store, err = openStore(cfg, key)
+			if err != nil {
+				return err
+			}
+		}
+
+		if err := cfg.Save(); err != nil {
+			return fmt.Errorf("failed to save config: %w", err)
+		}
+
+		if store != nil && store.Delete(accountName) {
+			if err := store.Save(); err != nil {
+				return err
+			}
+		}
+
+		st, err := state.Load()
+		if err != nil {
+			return fmt.Errorf("failed to load state: %w", err)
+		}
+		if st.Account == accountName {
+			if err := state.Clear(); err != nil {
+				return err
+			}
+		}
+
+		fmt.Printf("Account '%s' removed.\n", accountName)
+		if defs := cfg.Definitions(accountName); len(defs) > 1 {
+			fmt.Printf("The definition from %s applies again.\n", defs[1])
+		}
+		return nil
+	})
 }
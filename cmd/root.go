@@ -2,14 +2,27 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"runtime/debug"
 
+	"github.com/naxodev/github-switch/internal/config"
+	"github.com/naxodev/github-switch/internal/env"
+	"github.com/naxodev/github-switch/internal/gh"
+	"github.com/naxodev/github-switch/internal/git"
+	"github.com/naxodev/github-switch/internal/secret"
+	"github.com/naxodev/github-switch/internal/ssh"
+	"github.com/naxodev/github-switch/internal/state"
 	"github.com/spf13/cobra"
 )
 
-var version = "dev"
+var (
+	version       = "dev"
+	configFile    string
+	sshConfigFile string
+	gitConfigFile string
+)
 
 var rootCmd = &cobra.Command{
 	Use:   "github-switch",
@@ -18,7 +31,11 @@ var rootCmd = &cobra.Command{
 by modifying SSH config and Git configuration.
 
 Use 'github-switch switch <account>' to switch accounts,
-or 'github-switch list' to see available accounts.`,
+or 'github-switch list' to see available accounts.
+
+The config is read from --config, $GITHUB_SWITCH_CONFIG or
+$XDG_CONFIG_HOME/github-switch/config.yaml (~/.config by default).`,
+	PersistentPreRunE: setup,
 }
 
 func init() {
@@ -29,10 +46,77 @@ func init() {
 	}
 	rootCmd.Version = version
 	rootCmd.SetVersionTemplate("github-switch version {{.Version}}\n")
+	rootCmd.PersistentFlags().StringVarP(&configFile, "config", "c", "", "Config file (default $XDG_CONFIG_HOME/github-switch/config.yaml)")
+	rootCmd.PersistentFlags().StringVar(&sshConfigFile, "ssh-config", "", "SSH config to manage; keys are looked up next to it (default ~/.ssh/config)")
+	rootCmd.PersistentFlags().StringVar(&gitConfigFile, "gitconfig", "", "Git config file to use as the global config")
+}
+
+// setup validates global flags, points the packages at the SSH and Git
+// files to manage, and locates the config, state and secrets files, moving
+// them out of the locations used by older releases.
+func setup(cmd *cobra.Command, args []string) error {
+	if err := validateOutputFormat(cmd); err != nil {
+		return err
+	}
+
+	e := env.Default()
+	if sshConfigFile != "" {
+		e.WithSSHConfig(sshConfigFile)
+	}
+	e.GitConfig = gitConfigFile
+	ssh.SetEnv(e)
+	git.SetEnv(e)
+	gh.SetEnv(e)
+
+	moved, err := config.Init(configFile)
+	if err != nil {
+		return fmt.Errorf("failed to locate config: %w", err)
+	}
+	if moved != "" {
+		fmt.Fprintf(os.Stderr, "Moved %s to %s\n", moved, config.GetConfigPath())
+	}
+
+	if moved, err = state.Init(); err != nil {
+		return fmt.Errorf("failed to locate state: %w", err)
+	}
+	if moved != "" {
+		fmt.Fprintf(os.Stderr, "Moved %s to %s\n", moved, state.GetStatePath())
+	}
+
+	if moved, err = secret.Init(); err != nil {
+		return fmt.Errorf("failed to locate secrets: %w", err)
+	}
+	if moved != "" {
+		fmt.Fprintf(os.Stderr, "Moved %s to %s\n", moved, secret.GetStorePath())
+	}
+
+	return nil
+}
+
+// exitError carries a process exit code for commands that have already
+// reported their outcome and only need to signal failure.
+type exitError struct {
+	code int
+}
+
+func (e *exitError) Error() string {
+	return fmt.Sprintf("exit status %d", e.code)
+}
+
+// exitWithCode returns an error that makes Execute exit with the given code
+// without printing anything further.
+func exitWithCode(cmd *cobra.Command, code int) error {
+	cmd.SilenceErrors = true
+	cmd.SilenceUsage = true
+	return &exitError{code: code}
 }
 
 func Execute() {
 	if err := rootCmd.Execute(); err != nil {
+		var exitErr *exitError
+		if errors.As(err, &exitErr) {
+			os.Exit(exitErr.code)
+		}
 		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
 	}
@@ -2,34 +2,49 @@ package cmd
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
 	"os"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/naxodev/github-switch/internal/config"
+	"github.com/naxodev/github-switch/internal/gh"
 	"github.com/naxodev/github-switch/internal/git"
+	"github.com/naxodev/github-switch/internal/picker"
+	"github.com/naxodev/github-switch/internal/project"
 	"github.com/naxodev/github-switch/internal/ssh"
+	"github.com/naxodev/github-switch/internal/state"
 	"github.com/spf13/cobra"
+	"golang.org/x/term"
 )
 
 var (
 	forceSwitch bool
+	switchTags  []string
 )
 
 var switchCmd = &cobra.Command{
 	Use:   "switch [account]",
 	Short: "Switch to a GitHub account",
 	Long: `Switch to a different GitHub account by updating SSH config
-and global Git configuration.
+and global Git configuration. When the account has a GitHub user and the
+GitHub CLI is logged in as that user, gh is switched to it as well.
 
-If no account is specified, an interactive menu will be shown.`,
+If no account is specified, the account required by a .github-switch
+marker file or directory rule for the current directory is used. Otherwise
+an interactive menu is shown: on a terminal, a full-screen picker that
+filters as you type on names, emails and tags and previews the settings
+each account applies; elsewhere, a numbered list. --tag limits the menu to
+accounts carrying every given tag, and skips it when only one account does.`,
 	Aliases: []string{"sw"},
 	RunE:    runSwitch,
 }
 
 func init() {
 	switchCmd.Flags().BoolVarP(&forceSwitch, "force", "f", false, "Skip confirmation prompt")
+	switchCmd.Flags().StringSliceVarP(&switchTags, "tag", "t", nil, "Choose among accounts with this tag (repeatable)")
 	rootCmd.AddCommand(switchCmd)
 }
 
@@ -43,21 +58,53 @@ func runSwitch(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("no accounts configured. Use 'github-switch add' to add an account")
 	}
 
+	binding, err := project.Resolve(cfg, ".")
+	if err != nil {
+		return err
+	}
+
 	var accountName string
-	if len(args) > 0 {
+	switch {
+	case len(args) > 0:
 		accountName = args[0]
-	} else {
-		accountName, err = selectAccount(cfg)
+	case len(switchTags) > 0:
+		names := cfg.FilterAccounts(switchTags)
+		switch len(names) {
+		case 0:
+			return fmt.Errorf("no accounts tagged %s", strings.Join(switchTags, ", "))
+		case 1:
+			accountName = names[0]
+			fmt.Printf("Using '%s', the only account tagged %s\n", accountName, strings.Join(switchTags, ", "))
+		default:
+			accountName, err = selectAccount(cfg, names)
+			if err != nil {
+				return err
+			}
+		}
+	case binding != nil:
+		accountName = binding.Account
+		fmt.Printf("Using account '%s' required by %s\n", accountName, binding.Source)
+	default:
+		accountName, err = selectAccount(cfg, cfg.ListAccounts())
 		if err != nil {
 			return err
 		}
 	}
+	if accountName == "" {
+		fmt.Println("Cancelled.")
+		return nil
+	}
 
 	account, ok := cfg.GetAccount(accountName)
 	if !ok {
 		return fmt.Errorf("unknown account: %s", accountName)
 	}
 
+	if binding != nil && !binding.Permits(accountName) {
+		fmt.Fprintf(os.Stderr, "Warning: this directory expects account %s (from %s), not '%s'.\n",
+			quoteList(binding.Accounts()), binding.Source, accountName)
+	}
+
 	if !forceSwitch {
 		fmt.Printf("Switch to account '%s'?\n", accountName)
 		fmt.Printf("  Name:    %s\n", account.Name)
@@ -75,37 +122,49 @@ func runSwitch(cmd *cobra.Command, args []string) error {
 		}
 	}
 
-	if err := ssh.UpdateConfig(account.SSHKey); err != nil {
-		return fmt.Errorf("failed to update SSH config: %w", err)
-	}
+	return locked(func() error {
+		// The config may have changed while the menu or prompt was open.
+		cfg, err := config.Load()
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+		account, ok := cfg.GetAccount(accountName)
+		if !ok {
+			return fmt.Errorf("unknown account: %s", accountName)
+		}
 
-	if err := git.UpdateGlobalConfig(account.Name, account.Email); err != nil {
-		return fmt.Errorf("failed to update Git config: %w", err)
-	}
+		if err := applyAccount(account); err != nil {
+			return err
+		}
 
-	if err := ssh.AddKeyToAgent(account.SSHKey); err != nil {
-		fmt.Fprintf(os.Stderr, "Warning: failed to add key to ssh-agent: %v\n", err)
-	}
+		st := &state.State{Account: accountName, SwitchedAt: time.Now()}
+		if err := st.Save(); err != nil {
+			return fmt.Errorf("failed to save state: %w", err)
+		}
 
-	fmt.Printf("Switched to GitHub account: %s\n", accountName)
-	return nil
+		fmt.Printf("Switched to GitHub account: %s\n", accountName)
+		return nil
+	})
 }
 
-func selectAccount(cfg *config.Config) (string, error) {
-	accounts := cfg.ListAccounts()
+// selectAccount asks the user to pick one of accounts with the full-screen
+// picker, or, when stdin or stdout isn't a terminal, a numbered menu where
+// accounts are chosen by number or name and entering a tag narrows the
+// list. An empty name means the user cancelled.
+func selectAccount(cfg *config.Config, accounts []string) (string, error) {
 	if len(accounts) == 0 {
 		return "", fmt.Errorf("no accounts available")
 	}
 
-	fmt.Println("Select an account:")
-	for i, name := range accounts {
-		acc, _ := cfg.GetAccount(name)
-		fmt.Printf("  %d. %s (%s)\n", i+1, name, acc.Email)
+	if term.IsTerminal(int(os.Stdin.Fd())) && term.IsTerminal(int(os.Stdout.Fd())) {
+		return pickAccount(cfg, accounts)
 	}
 
+	printMenu(cfg, accounts)
+
 	reader := bufio.NewReader(os.Stdin)
 	for {
-		fmt.Printf("Enter your choice (1-%d): ", len(accounts))
+		fmt.Printf("Enter your choice (1-%d), or a tag to narrow the list: ", len(accounts))
 		input, err := reader.ReadString('\n')
 		if err != nil {
 			return "", fmt.Errorf("failed to read input: %w", err)
@@ -123,6 +182,131 @@ func selectAccount(cfg *config.Config) (string, error) {
 			}
 		}
 
+		var tagged []string
+		for _, name := range accounts {
+			if acc, _ := cfg.GetAccount(name); input != "" && acc.HasTags(input) {
+				tagged = append(tagged, name)
+			}
+		}
+		if len(tagged) > 0 {
+			accounts = tagged
+			printMenu(cfg, accounts)
+			continue
+		}
+
 		fmt.Println("Invalid selection. Please try again.")
 	}
 }
+
+func printMenu(cfg *config.Config, accounts []string) {
+	fmt.Println("Select an account:")
+	for i, name := range accounts {
+		acc, _ := cfg.GetAccount(name)
+		if len(acc.Tags) > 0 {
+			fmt.Printf("  %d. %s (%s) [%s]\n", i+1, name, acc.Email, strings.Join(acc.Tags, ", "))
+		} else {
+			fmt.Printf("  %d. %s (%s)\n", i+1, name, acc.Email)
+		}
+	}
+}
+
+func pickAccount(cfg *config.Config, accounts []string) (string, error) {
+	st, err := state.Load()
+	if err != nil {
+		return "", fmt.Errorf("failed to load state: %w", err)
+	}
+
+	items := make([]picker.Item, len(accounts))
+	for i, name := range accounts {
+		acc, _ := cfg.GetAccount(name)
+		items[i] = picker.Item{
+			Name:    name,
+			Detail:  acc.Email,
+			Tags:    acc.Tags,
+			Preview: previewAccount(name, acc, name == st.Account),
+			Active:  name == st.Account,
+		}
+	}
+
+	i, err := picker.Run(os.Stdin, os.Stdout, "Select an account", items)
+	if errors.Is(err, picker.ErrCancelled) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return accounts[i], nil
+}
+
+// previewAccount describes the Git and SSH settings switching to an account
+// would apply.
+func previewAccount(name string, acc config.Account, active bool) []string {
+	title := name
+	if active {
+		title += " (active)"
+	}
+	lines := []string{title}
+	if acc.Extends != "" {
+		lines = append(lines, "Extends      "+acc.Extends)
+	}
+	if len(acc.Tags) > 0 {
+		lines = append(lines, "Tags         "+strings.Join(acc.Tags, ", "))
+	}
+
+	lines = append(lines,
+		"",
+		"Git",
+		"  user.name     "+acc.Name,
+		"  user.email    "+acc.Email,
+		"",
+		// The block UpdateConfig writes, whatever host the account uses.
+		"SSH",
+		"  Host          github.com",
+	)
+	identity := ssh.IdentityFile(acc.SSHKey)
+	if _, err := os.Stat(ssh.KeyPath(acc.SSHKey)); err != nil {
+		identity += " (missing)"
+	}
+	lines = append(lines, "  IdentityFile  "+identity)
+	if acc.User != "" {
+		lines = append(lines, "GitHub user  "+acc.User)
+	}
+	return lines
+}
+
+// applyAccount points the SSH config, global Git config, ssh-agent and, when
+// possible, the GitHub CLI at account.
+func applyAccount(account config.Account) error {
+	if err := ssh.UpdateConfig(account.SSHKey); err != nil {
+		return fmt.Errorf("failed to update SSH config: %w", err)
+	}
+
+	if err := git.UpdateGlobalConfig(account.Name, account.Email); err != nil {
+		return fmt.Errorf("failed to update Git config: %w", err)
+	}
+
+	if err := ssh.AddKeyToAgent(account.SSHKey); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to add key to ssh-agent: %v\n", err)
+	}
+
+	if account.User != "" {
+		if loggedIn, _ := gh.LoggedIn(account.Hostname()); loggedIn {
+			if err := gh.Switch(account.Hostname(), account.User); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: failed to switch gh account: %v\n", err)
+			}
+		}
+	}
+	return nil
+}
+
+// quoteList renders names as 'a', 'b' or 'c' for messages.
+func quoteList(names []string) string {
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = "'" + name + "'"
+	}
+	if len(quoted) == 1 {
+		return quoted[0]
+	}
+	return strings.Join(quoted[:len(quoted)-1], ", ") + " or " + quoted[len(quoted)-1]
+}
@@ -0,0 +1,301 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/naxodev/github-switch/internal/config"
+	"github.com/naxodev/github-switch/internal/secret"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+const (
+	envPassphrase = "GITHUB_SWITCH_PASSPHRASE"
+	envKeyFile    = "GITHUB_SWITCH_KEY_FILE"
+)
+
+var tokenCmd = &cobra.Command{
+	Use:   "token",
+	Short: "Manage encrypted account tokens",
+	Long: `Manage the personal access tokens served by the credential helper.
+
+Tokens are encrypted at rest in $XDG_DATA_HOME/github-switch/secrets.yaml
+(~/.local/share by default). The key is
+derived from the file named by ` + envKeyFile + ` (for example an age
+identity) if set, otherwise from ` + envPassphrase + ` or a passphrase
+prompted for on the terminal.`,
+}
+
+var tokenSetCmd = &cobra.Command{
+	Use:   "set <account>",
+	Short: "Store the token for an account",
+	Long: `Store the token for an account. The token is read from standard input
+when it is not a terminal, otherwise it is prompted for.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runTokenSet,
+}
+
+var tokenGetCmd = &cobra.Command{
+	Use:   "get <account>",
+	Short: "Print the token for an account",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runTokenGet,
+}
+
+var tokenRemoveCmd = &cobra.Command{
+	Use:     "rm <account>",
+	Short:   "Remove the token for an account",
+	Aliases: []string{"remove"},
+	Args:    cobra.ExactArgs(1),
+	RunE:    runTokenRemove,
+}
+
+func init() {
+	tokenCmd.AddCommand(tokenSetCmd, tokenGetCmd, tokenRemoveCmd)
+	rootCmd.AddCommand(tokenCmd)
+}
+
+func runTokenSet(cmd *cobra.Command, args []string) error {
+	accountName := args[0]
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	if _, ok := cfg.GetAccount(accountName); !ok {
+		return fmt.Errorf("account '%s' not found", accountName)
+	}
+
+	var token string
+	if term.IsTerminal(int(os.Stdin.Fd())) {
+		fmt.Fprintf(os.Stderr, "Token for '%s': ", accountName)
+		data, err := term.ReadPassword(int(os.Stdin.Fd()))
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return fmt.Errorf("failed to read token: %w", err)
+		}
+		token = string(data)
+	} else {
+		token, _ = bufio.NewReader(os.Stdin).ReadString('\n')
+	}
+	token = strings.TrimSpace(token)
+	if token == "" {
+		return fmt.Errorf("token must not be empty")
+	}
+
+	key, err := secretKey()
+	if err != nil {
+		return err
+	}
+
+	return locked(func() error {
+		cfg, err := config.Load()
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+		store, err := openStore(cfg, key)
+		if err != nil {
+			return err
+		}
+		store.Set(accountName, token)
+		if err := store.Save(); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(os.Stderr, "Token for '%s' saved.\n", accountName)
+		return nil
+	})
+}
+
+func runTokenGet(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	store, err := readSecrets(cfg)
+	if err != nil {
+		return err
+	}
+
+	token, ok := store.Get(args[0])
+	if !ok {
+		return fmt.Errorf("no token stored for account '%s'", args[0])
+	}
+
+	fmt.Println(token)
+	return nil
+}
+
+func runTokenRemove(cmd *cobra.Command, args []string) error {
+	key, err := secretKey()
+	if err != nil {
+		return err
+	}
+
+	return locked(func() error {
+		cfg, err := config.Load()
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+		store, err := openStore(cfg, key)
+		if err != nil {
+			return err
+		}
+
+		if !store.Delete(args[0]) {
+			return fmt.Errorf("no token stored for account '%s'", args[0])
+		}
+		if err := store.Save(); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(os.Stderr, "Token for '%s' removed.\n", args[0])
+		return nil
+	})
+}
+
+// hasSecrets reports whether there are any tokens to read, so callers that
+// run non-interactively can avoid prompting for a passphrase needlessly.
+func hasSecrets(cfg *config.Config) bool {
+	return secret.Exists() || hasPlaintextTokens(cfg)
+}
+
+// hasPlaintextTokens reports whether the config has tokens that openStore
+// would move into the store. Tokens in included files are left alone.
+func hasPlaintextTokens(cfg *config.Config) bool {
+	for name, acc := range cfg.Accounts {
+		if acc.Token != "" && !cfg.ReadOnly(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// openSecrets unlocks the secret store and moves any plaintext tokens left
+// in the config into it. The caller holds the lock.
+func openSecrets(cfg *config.Config) (*secret.Store, error) {
+	key, err := secretKey()
+	if err != nil {
+		return nil, err
+	}
+	return openStore(cfg, key)
+}
+
+// readSecrets unlocks the secret store for reading. The lock is only taken,
+// after the passphrase has been entered, when plaintext tokens have to be
+// moved out of the config.
+func readSecrets(cfg *config.Config) (*secret.Store, error) {
+	key, err := secretKey()
+	if err != nil {
+		return nil, err
+	}
+
+	if !hasPlaintextTokens(cfg) {
+		store, err := secret.Open(key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to unlock secrets: %w", err)
+		}
+		return store, nil
+	}
+
+	var store *secret.Store
+	err = locked(func() error {
+		cfg, err := config.Load()
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+		store, err = openStore(cfg, key)
+		return err
+	})
+	return store, err
+}
+
+// openStore is openSecrets with the key already known.
+func openStore(cfg *config.Config, key secret.Key) (*secret.Store, error) {
+	store, err := secret.Open(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unlock secrets: %w", err)
+	}
+
+	migrated := 0
+	for name, acc := range cfg.Accounts {
+		if acc.Token == "" {
+			continue
+		}
+		if cfg.ReadOnly(name) {
+			// Clearing it would copy the account into the user's config.
+			fmt.Fprintf(os.Stderr, "Warning: account '%s' has a plaintext token in %s, which github-switch does not write to; store it with 'github-switch token set %s' and delete it there.\n",
+				name, strings.Join(cfg.Definitions(name), ", "), name)
+			continue
+		}
+		if _, ok := store.Get(name); !ok {
+			store.Set(name, acc.Token)
+		}
+		acc.Token = ""
+		cfg.AddAccount(name, acc)
+		migrated++
+	}
+
+	if migrated > 0 {
+		if err := store.Save(); err != nil {
+			return nil, err
+		}
+		if err := cfg.Save(); err != nil {
+			return nil, fmt.Errorf("failed to save config: %w", err)
+		}
+		fmt.Fprintf(os.Stderr, "Moved plaintext tokens for %d account(s) into %s.\n", migrated, secret.GetStorePath())
+	}
+
+	return store, nil
+}
+
+func secretKey() (secret.Key, error) {
+	if path := os.Getenv(envKeyFile); path != "" {
+		return secret.KeyFile(path)
+	}
+	if passphrase := os.Getenv(envPassphrase); passphrase != "" {
+		return secret.Passphrase(passphrase), nil
+	}
+
+	// Read from the terminal directly: the credential helper's stdin
+	// carries the Git protocol.
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return secret.Key{}, fmt.Errorf("no terminal to prompt for a passphrase; set %s or %s", envPassphrase, envKeyFile)
+	}
+	defer tty.Close()
+
+	passphrase, err := readPassphrase(tty, "Passphrase for github-switch secrets: ")
+	if err != nil {
+		return secret.Key{}, err
+	}
+
+	if !secret.Exists() {
+		confirm, err := readPassphrase(tty, "Confirm new passphrase: ")
+		if err != nil {
+			return secret.Key{}, err
+		}
+		if confirm != passphrase {
+			return secret.Key{}, fmt.Errorf("passphrases do not match")
+		}
+	}
+
+	return secret.Passphrase(passphrase), nil
+}
+
+func readPassphrase(tty *os.File, prompt string) (string, error) {
+	fmt.Fprint(tty, prompt)
+	data, err := term.ReadPassword(int(tty.Fd()))
+	fmt.Fprintln(tty)
+	if err != nil {
+		return "", fmt.Errorf("failed to read passphrase: %w", err)
+	}
+	if len(data) == 0 {
+		return "", fmt.Errorf("passphrase must not be empty")
+	}
+	return string(data), nil
+}
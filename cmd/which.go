@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/naxodev/github-switch/internal/config"
+	"github.com/naxodev/github-switch/internal/project"
+	"github.com/spf13/cobra"
+)
+
+var whichCmd = &cobra.Command{
+	Use:   "which [directory]",
+	Short: "Show which account a repository should use",
+	Long: `Resolve the account a directory (the current one by default) should use,
+from a .github-switch marker file, the remote rules in the config matched
+against the repository's remotes, or the directory rules, in that order.
+
+Exits with a non-zero status when no account is bound.`,
+	Args:        cobra.MaximumNArgs(1),
+	Annotations: map[string]string{outputAnnotation: "true"},
+	RunE:        runWhich,
+}
+
+func init() {
+	rootCmd.AddCommand(whichCmd)
+}
+
+type whichView struct {
+	Account string   `json:"account" yaml:"account"`
+	Allowed []string `json:"allowed,omitempty" yaml:"allowed,omitempty"`
+	Source  string   `json:"source" yaml:"source"`
+}
+
+func runWhich(cmd *cobra.Command, args []string) error {
+	dir := "."
+	if len(args) > 0 {
+		dir = args[0]
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	binding, err := project.Resolve(cfg, dir)
+	if err != nil {
+		return err
+	}
+	if binding == nil {
+		fmt.Fprintln(os.Stderr, "No account is bound to this directory.")
+		return exitWithCode(cmd, 1)
+	}
+
+	if _, ok := cfg.GetAccount(binding.Account); !ok {
+		fmt.Fprintf(os.Stderr, "Warning: account '%s' is not configured.\n", binding.Account)
+	}
+
+	if structuredOutput() {
+		return printStructured(whichView{
+			Account: binding.Account,
+			Allowed: binding.Allowed,
+			Source:  binding.Source,
+		})
+	}
+
+	fmt.Printf("%s (from %s)\n", binding.Account, binding.Source)
+	return nil
+}
@@ -0,0 +1,329 @@
+// Package bundle converts account definitions to and from a portable file
+// that can be shared between machines.
+package bundle
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/naxodev/github-switch/internal/config"
+	"github.com/naxodev/github-switch/internal/ssh"
+	"gopkg.in/yaml.v3"
+)
+
+// FormatVersion is the bundle format written by this version.
+const FormatVersion = 1
+
+// ErrNewerFormat is returned for bundles written by a newer github-switch.
+var ErrNewerFormat = errors.New("bundle was written by a newer version of github-switch")
+
+// Bundle is a portable set of accounts. Tokens are never included.
+type Bundle struct {
+	Version  int                `json:"version" yaml:"version"`
+	Accounts map[string]Account `json:"accounts" yaml:"accounts"`
+}
+
+// Account is an account as stored in a bundle. SSHKey may be a template
+// such as id_{{.Account}}, expanded when the bundle is imported. PublicKey,
+// when present, is used to find the matching key whatever its file name.
+type Account struct {
+	SSHKey    string   `json:"ssh_key" yaml:"ssh_key"`
+	Name      string   `json:"name" yaml:"name"`
+	Email     string   `json:"email" yaml:"email"`
+	Host      string   `json:"host,omitempty" yaml:"host,omitempty"`
+	User      string   `json:"user,omitempty" yaml:"user,omitempty"`
+	Tags      []string `json:"tags,omitempty" yaml:"tags,omitempty"`
+	PublicKey string   `json:"public_key,omitempty" yaml:"public_key,omitempty"`
+}
+
+// ExportOptions tunes FromConfig.
+type ExportOptions struct {
+	// PublicKeys embeds the content of each key's .pub file.
+	PublicKeys bool
+	// KeyTemplate, when set, replaces every key file name in the bundle.
+	KeyTemplate string
+}
+
+// FromConfig builds a bundle of the named accounts, or of all of them.
+func FromConfig(cfg *config.Config, names []string, opts ExportOptions) (*Bundle, error) {
+	if len(names) == 0 {
+		names = cfg.ListAccounts()
+	}
+
+	b := &Bundle{Version: FormatVersion, Accounts: make(map[string]Account)}
+	for _, name := range names {
+		acc, ok := cfg.GetAccount(name)
+		if !ok {
+			return nil, fmt.Errorf("account '%s' not found", name)
+		}
+
+		exported := Account{
+			SSHKey: acc.SSHKey,
+			Name:   acc.Name,
+			Email:  acc.Email,
+			Host:   acc.Host,
+			User:   acc.User,
+			Tags:   acc.Tags,
+		}
+		if opts.KeyTemplate != "" {
+			// .Key is the key name on this machine. The other fields are
+			// left in place for import to expand.
+			key, err := render(opts.KeyTemplate, keyData{
+				Account: "{{.Account}}",
+				Key:     acc.SSHKey,
+				Host:    "{{.Host}}",
+				User:    "{{.User}}",
+				Email:   "{{.Email}}",
+			})
+			if err != nil {
+				return nil, fmt.Errorf("invalid key template: %w", err)
+			}
+			exported.SSHKey = key
+		}
+		if opts.PublicKeys {
+			pub, err := os.ReadFile(ssh.KeyPath(acc.SSHKey) + ".pub")
+			if err != nil {
+				return nil, fmt.Errorf("failed to read public key of '%s': %w", name, err)
+			}
+			exported.PublicKey = strings.TrimSpace(string(pub))
+		}
+		b.Accounts[name] = exported
+	}
+	return b, nil
+}
+
+// Marshal encodes the bundle as "yaml" or "json".
+func (b *Bundle) Marshal(format string) ([]byte, error) {
+	switch format {
+	case "json":
+		data, err := json.MarshalIndent(b, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal bundle: %w", err)
+		}
+		return append(data, '\n'), nil
+	case "yaml":
+		var buf bytes.Buffer
+		enc := yaml.NewEncoder(&buf)
+		enc.SetIndent(2)
+		if err := enc.Encode(b); err != nil {
+			return nil, fmt.Errorf("failed to marshal bundle: %w", err)
+		}
+		if err := enc.Close(); err != nil {
+			return nil, fmt.Errorf("failed to marshal bundle: %w", err)
+		}
+		return buf.Bytes(), nil
+	default:
+		return nil, fmt.Errorf("unsupported bundle format '%s'", format)
+	}
+}
+
+// Parse decodes a YAML or JSON bundle.
+func Parse(data []byte) (*Bundle, error) {
+	var b Bundle
+	// JSON is valid YAML, so one decoder reads both formats.
+	if err := yaml.Unmarshal(data, &b); err != nil {
+		return nil, fmt.Errorf("failed to parse bundle: %w", err)
+	}
+	if b.Version > FormatVersion {
+		return nil, fmt.Errorf("%w (format %d, supported up to %d)", ErrNewerFormat, b.Version, FormatVersion)
+	}
+	return &b, nil
+}
+
+// Conflict strategies for accounts that already exist.
+const (
+	Skip      = "skip"
+	Overwrite = "overwrite"
+	Rename    = "rename"
+)
+
+// ImportOptions tunes Merge.
+type ImportOptions struct {
+	// OnConflict is Skip, Overwrite or Rename.
+	OnConflict string
+	// KeyTemplate, when set, replaces the key file names of the bundle.
+	KeyTemplate string
+}
+
+// Result reports what Merge did with each account of the bundle.
+type Result struct {
+	Added       []string
+	Overwritten []string
+	Skipped     []string
+	// Renamed maps bundle names to the names the accounts were added under.
+	Renamed  map[string]string
+	Warnings []string
+}
+
+// keyData is available to key templates.
+type keyData struct {
+	Account string
+	Key     string
+	Host    string
+	User    string
+	Email   string
+}
+
+// Merge adds the bundle's accounts to cfg. Key file names are resolved by
+// matching the embedded public key against the .pub files in the SSH
+// directory, falling back to the (templated) name from the bundle.
+func Merge(cfg *config.Config, b *Bundle, opts ImportOptions) (*Result, error) {
+	switch opts.OnConflict {
+	case "", Skip, Overwrite, Rename:
+	default:
+		return nil, fmt.Errorf("unknown conflict strategy '%s' (use skip, overwrite or rename)", opts.OnConflict)
+	}
+
+	if cfg.Accounts == nil {
+		cfg.Accounts = make(map[string]config.Account)
+	}
+
+	publicKeys, err := localPublicKeys()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Result{Renamed: make(map[string]string)}
+	for _, name := range sortedNames(b.Accounts) {
+		acc := b.Accounts[name]
+
+		key, err := resolveKey(name, acc, opts.KeyTemplate, publicKeys)
+		if err != nil {
+			return nil, err
+		}
+		keyPath := ssh.KeyPath(key)
+		if _, err := os.Stat(keyPath); err != nil {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("key file %s for '%s' does not exist", keyPath, name))
+		}
+
+		target := name
+		if _, exists := cfg.GetAccount(name); exists {
+			switch opts.OnConflict {
+			case Overwrite:
+				result.Overwritten = append(result.Overwritten, name)
+			case Rename:
+				target = freeName(cfg, name)
+				result.Renamed[name] = target
+			default:
+				result.Skipped = append(result.Skipped, name)
+				continue
+			}
+		} else {
+			result.Added = append(result.Added, name)
+		}
+
+		existing := cfg.Accounts[target]
+		cfg.AddAccount(target, config.Account{
+			SSHKey: key,
+			Name:   acc.Name,
+			Email:  acc.Email,
+			Host:   acc.Host,
+			User:   acc.User,
+			Tags:   acc.Tags,
+			// Bundles carry no tokens; keep a plaintext one not yet migrated.
+			Token: existing.Token,
+		})
+	}
+	return result, nil
+}
+
+func resolveKey(name string, acc Account, keyTemplate string, publicKeys map[string]string) (string, error) {
+	if acc.PublicKey != "" {
+		if key, ok := publicKeys[keyMaterial(acc.PublicKey)]; ok {
+			return key, nil
+		}
+	}
+
+	data := keyData{Account: name, Host: acc.Host, User: acc.User, Email: acc.Email}
+	key, err := render(acc.SSHKey, data)
+	if err != nil {
+		return "", fmt.Errorf("invalid key template for '%s': %w", name, err)
+	}
+	if keyTemplate == "" {
+		return key, nil
+	}
+
+	data.Key = key
+	key, err = render(keyTemplate, data)
+	if err != nil {
+		return "", fmt.Errorf("invalid key template: %w", err)
+	}
+	return key, nil
+}
+
+func render(text string, data keyData) (string, error) {
+	if !strings.Contains(text, "{{") {
+		return text, nil
+	}
+	tmpl, err := template.New("key").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// localPublicKeys maps the key material of every .pub file in the SSH
+// directory to the name of its private key.
+func localPublicKeys() (map[string]string, error) {
+	keys := make(map[string]string)
+
+	entries, err := os.ReadDir(ssh.KeyDir())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return keys, nil
+		}
+		return nil, fmt.Errorf("failed to read SSH directory: %w", err)
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".pub") {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(ssh.KeyDir(), entry.Name()))
+		if err != nil {
+			continue
+		}
+		keys[keyMaterial(string(data))] = strings.TrimSuffix(entry.Name(), ".pub")
+	}
+	return keys, nil
+}
+
+// keyMaterial returns the type and base64 blob of an authorized_keys style
+// public key, ignoring its comment.
+func keyMaterial(pub string) string {
+	fields := strings.Fields(pub)
+	if len(fields) < 2 {
+		return strings.TrimSpace(pub)
+	}
+	return fields[0] + " " + fields[1]
+}
+
+func freeName(cfg *config.Config, name string) string {
+	for i := 2; ; i++ {
+		candidate := name + "-" + strconv.Itoa(i)
+		if _, exists := cfg.GetAccount(candidate); !exists {
+			return candidate
+		}
+	}
+}
+
+func sortedNames(accounts map[string]Account) []string {
+	names := make([]string, 0, len(accounts))
+	for name := range accounts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,168 @@
+package bundle
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/naxodev/github-switch/internal/config"
+	"github.com/naxodev/github-switch/internal/env"
+	"github.com/naxodev/github-switch/internal/ssh"
+)
+
+func setupSSHDir(t *testing.T, keys map[string]string) {
+	t.Helper()
+	dir := t.TempDir()
+	for name, pub := range keys {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name+".pub"), []byte(pub+"\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ssh.SetEnv(&env.Env{SSHDir: dir})
+	t.Cleanup(func() { ssh.SetEnv(env.Default()) })
+}
+
+func TestExportRoundTrip(t *testing.T) {
+	setupSSHDir(t, map[string]string{"id_work": "ssh-ed25519 AAAAwork me@laptop"})
+
+	cfg := &config.Config{Accounts: map[string]config.Account{
+		"work":     {SSHKey: "id_work", Name: "Work Me", Email: "me@work.example", Token: "secret"},
+		"personal": {SSHKey: "id_personal", Name: "Me", Email: "me@home.example"},
+	}}
+
+	b, err := FromConfig(cfg, []string{"work"}, ExportOptions{PublicKeys: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, format := range []string{"yaml", "json"} {
+		data, err := b.Marshal(format)
+		if err != nil {
+			t.Fatalf("marshal %s: %v", format, err)
+		}
+		if strings.Contains(string(data), "secret") {
+			t.Errorf("expected tokens to be left out of the %s bundle:\n%s", format, data)
+		}
+
+		parsed, err := Parse(data)
+		if err != nil {
+			t.Fatalf("parse %s: %v", format, err)
+		}
+		if len(parsed.Accounts) != 1 || parsed.Accounts["work"].PublicKey != "ssh-ed25519 AAAAwork me@laptop" {
+			t.Errorf("unexpected %s round trip: %+v", format, parsed)
+		}
+	}
+
+	if _, err := FromConfig(cfg, []string{"personal"}, ExportOptions{PublicKeys: true}); err == nil {
+		t.Error("expected an error for a missing public key")
+	}
+}
+
+func TestParseRefusesNewerFormat(t *testing.T) {
+	if _, err := Parse([]byte("version: 99\naccounts: {}\n")); !errors.Is(err, ErrNewerFormat) {
+		t.Errorf("expected ErrNewerFormat, got %v", err)
+	}
+}
+
+func TestMergeResolvesKeys(t *testing.T) {
+	setupSSHDir(t, map[string]string{
+		"github_work":   "ssh-ed25519 AAAAwork other-comment",
+		"id_ed25519_me": "ssh-ed25519 AAAAme",
+	})
+
+	b := &Bundle{Version: FormatVersion, Accounts: map[string]Account{
+		"work":     {SSHKey: "id_work", Email: "w@example.com", PublicKey: "ssh-ed25519 AAAAwork me@laptop"},
+		"me":       {SSHKey: "id_{{.Account}}"},
+		"personal": {SSHKey: "id_personal"},
+	}}
+
+	cfg := &config.Config{}
+	result, err := Merge(cfg, b, ImportOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := map[string]string{"work": "github_work", "me": "id_me", "personal": "id_personal"}
+	for name, key := range expected {
+		if acc, _ := cfg.GetAccount(name); acc.SSHKey != key {
+			t.Errorf("expected '%s' to use key %s, got %s", name, key, acc.SSHKey)
+		}
+	}
+	if len(result.Added) != 3 || len(result.Warnings) != 2 {
+		t.Errorf("unexpected result: %+v", result)
+	}
+
+	cfg = &config.Config{}
+	if _, err := Merge(cfg, b, ImportOptions{KeyTemplate: "{{.Key}}_ed25519_{{.Account}}"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if acc, _ := cfg.GetAccount("me"); acc.SSHKey != "id_me_ed25519_me" {
+		t.Errorf("expected the key template to apply, got %s", acc.SSHKey)
+	}
+	if acc, _ := cfg.GetAccount("work"); acc.SSHKey != "github_work" {
+		t.Errorf("expected a matching public key to win over the template, got %s", acc.SSHKey)
+	}
+}
+
+func TestMergeConflicts(t *testing.T) {
+	setupSSHDir(t, nil)
+
+	b := &Bundle{Version: FormatVersion, Accounts: map[string]Account{
+		"work": {SSHKey: "id_new", Email: "new@example.com"},
+	}}
+	newConfig := func() *config.Config {
+		return &config.Config{Accounts: map[string]config.Account{
+			"work": {SSHKey: "id_old", Email: "old@example.com"},
+		}}
+	}
+
+	cfg := newConfig()
+	result, err := Merge(cfg, b, ImportOptions{OnConflict: Skip})
+	if err != nil || len(result.Skipped) != 1 || cfg.Accounts["work"].SSHKey != "id_old" {
+		t.Errorf("skip: unexpected result %+v, %v, %+v", result, err, cfg.Accounts)
+	}
+
+	cfg = newConfig()
+	result, err = Merge(cfg, b, ImportOptions{OnConflict: Overwrite})
+	if err != nil || len(result.Overwritten) != 1 || cfg.Accounts["work"].SSHKey != "id_new" {
+		t.Errorf("overwrite: unexpected result %+v, %v, %+v", result, err, cfg.Accounts)
+	}
+
+	cfg = newConfig()
+	result, err = Merge(cfg, b, ImportOptions{OnConflict: Rename})
+	if err != nil || result.Renamed["work"] != "work-2" || cfg.Accounts["work-2"].SSHKey != "id_new" || cfg.Accounts["work"].SSHKey != "id_old" {
+		t.Errorf("rename: unexpected result %+v, %v, %+v", result, err, cfg.Accounts)
+	}
+
+	if _, err := Merge(newConfig(), b, ImportOptions{OnConflict: "merge"}); err == nil {
+		t.Error("expected an error for an unknown strategy")
+	}
+}
+
+func TestExportKeyTemplate(t *testing.T) {
+	setupSSHDir(t, nil)
+
+	cfg := &config.Config{Accounts: map[string]config.Account{
+		"work": {SSHKey: "id_work", Name: "Work Me", Email: "me@work.example"},
+	}}
+	b, err := FromConfig(cfg, nil, ExportOptions{KeyTemplate: "{{.Key}}_{{.Account}}"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := b.Accounts["work"].SSHKey; got != "id_work_{{.Account}}" {
+		t.Errorf("expected .Key to be filled in on export, got %s", got)
+	}
+
+	cfg = &config.Config{Accounts: map[string]config.Account{}}
+	if _, err := Merge(cfg, b, ImportOptions{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if acc, _ := cfg.GetAccount("work"); acc.SSHKey != "id_work_work" {
+		t.Errorf("expected .Account to be expanded on import, got %s", acc.SSHKey)
+	}
+}
@@ -0,0 +1,89 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// annotationPrefix marks comment lines written by Annotate, so that
+// StripAnnotations can remove them again before the next validation.
+const annotationPrefix = "# github-switch: "
+
+var syntaxLine = regexp.MustCompile(`line (\d+):`)
+
+// SyntaxIssue turns a YAML syntax error returned by Validate into an Issue,
+// positioned at the line it mentions or at the top of the file.
+func SyntaxIssue(err error) Issue {
+	issue := Issue{Line: 1, Column: 1, Severity: SeverityError, Message: err.Error()}
+	if m := syntaxLine.FindStringSubmatch(err.Error()); m != nil {
+		if line, convErr := strconv.Atoi(m[1]); convErr == nil && line > 0 {
+			issue.Line = line
+		}
+	}
+	return issue
+}
+
+// Annotate inserts a comment describing each issue above the line it
+// concerns, indented to match it.
+func Annotate(data []byte, issues []Issue) []byte {
+	byLine := make(map[int][]Issue)
+	for _, issue := range issues {
+		byLine[issue.Line] = append(byLine[issue.Line], issue)
+	}
+
+	lines := strings.SplitAfter(string(data), "\n")
+	var b strings.Builder
+	for i, line := range lines {
+		indent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+		for _, issue := range byLine[i+1] {
+			b.WriteString(indent + annotationPrefix + describe(issue) + "\n")
+		}
+		delete(byLine, i+1)
+		b.WriteString(line)
+	}
+
+	// Issues past the end of the file, e.g. an unexpected end of input.
+	if len(byLine) > 0 {
+		if !strings.HasSuffix(b.String(), "\n") && b.Len() > 0 {
+			b.WriteString("\n")
+		}
+		for _, line := range sortedKeys(byLine) {
+			for _, issue := range byLine[line] {
+				b.WriteString(annotationPrefix + describe(issue) + "\n")
+			}
+		}
+	}
+	return []byte(b.String())
+}
+
+// StripAnnotations removes the comments inserted by Annotate.
+func StripAnnotations(data []byte) []byte {
+	lines := strings.SplitAfter(string(data), "\n")
+	var b strings.Builder
+	for _, line := range lines {
+		if strings.HasPrefix(strings.TrimLeft(line, " \t"), annotationPrefix) {
+			continue
+		}
+		b.WriteString(line)
+	}
+	return []byte(b.String())
+}
+
+func describe(issue Issue) string {
+	if issue.Path == "" {
+		return fmt.Sprintf("%s: %s", issue.Severity, issue.Message)
+	}
+	return fmt.Sprintf("%s: %s: %s (column %d)", issue.Severity, issue.Path, issue.Message, issue.Column)
+}
+
+func sortedKeys(m map[int][]Issue) []int {
+	keys := make([]int, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Ints(keys)
+	return keys
+}
@@ -0,0 +1,40 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAnnotateRoundTrip(t *testing.T) {
+	data := "accounts:\n  work:\n    email: bad\n"
+	issues, err := Validate([]byte(data), ValidateOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	annotated := string(Annotate([]byte(data), issues))
+	if !strings.Contains(annotated, "    "+annotationPrefix+"error: accounts.work.email: 'bad' is not a valid email address (column 12)\n    email: bad") {
+		t.Errorf("expected the email issue above its line, got:\n%s", annotated)
+	}
+
+	if stripped := string(StripAnnotations([]byte(annotated))); stripped != data {
+		t.Errorf("expected annotations to be stripped, got:\n%s", stripped)
+	}
+}
+
+func TestSyntaxIssue(t *testing.T) {
+	_, err := Validate([]byte("accounts:\n  work:\n\tbad\n"), ValidateOptions{})
+	if err == nil {
+		t.Fatal("expected a syntax error")
+	}
+
+	issue := SyntaxIssue(err)
+	if issue.Line != 3 || issue.Severity != SeverityError {
+		t.Errorf("expected a positioned error, got %+v", issue)
+	}
+
+	annotated := string(Annotate([]byte("a: [\n"), []Issue{{Line: 9, Severity: SeverityError, Message: "unexpected end"}}))
+	if !strings.HasSuffix(annotated, annotationPrefix+"error: unexpected end\n") {
+		t.Errorf("expected issues past the end to be appended, got:\n%s", annotated)
+	}
+}
@@ -6,47 +6,199 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"sort"
 
+	"github.com/naxodev/github-switch/internal/xdg"
 	"gopkg.in/yaml.v3"
 )
 
+// DefaultHost is the GitHub host accounts belong to unless they set Host.
+const DefaultHost = "github.com"
+
 type Account struct {
 	SSHKey string `yaml:"ssh_key"`
 	Name   string `yaml:"name"`
 	Email  string `yaml:"email"`
+	// Host is the GitHub host of the account, for GitHub Enterprise.
+	Host string `yaml:"host,omitempty"`
+	// User is the GitHub login, used for HTTPS credentials.
+	User string `yaml:"user,omitempty"`
+	// Token is a plaintext personal access token.
+	//
+	// Deprecated: tokens live in the encrypted secret store; plaintext tokens
+	// are moved there the next time the store is unlocked.
+	Token string `yaml:"token,omitempty"`
+	// Extends names a profile, or another account, whose fields this account
+	// inherits unless it sets them itself.
+	Extends string `yaml:"extends,omitempty"`
+	// Tags group accounts, e.g. client or oss, for filtering.
+	Tags []string `yaml:"tags,omitempty"`
+}
+
+// Equal reports whether two accounts have the same fields.
+func (a Account) Equal(b Account) bool {
+	return a.SSHKey == b.SSHKey && a.Name == b.Name && a.Email == b.Email &&
+		a.Host == b.Host && a.User == b.User && a.Token == b.Token &&
+		a.Extends == b.Extends && slices.Equal(a.Tags, b.Tags)
+}
+
+// HasTags reports whether the account carries every one of tags.
+func (a Account) HasTags(tags ...string) bool {
+	for _, tag := range tags {
+		if !slices.Contains(a.Tags, tag) {
+			return false
+		}
+	}
+	return true
+}
+
+// Hostname returns the account's GitHub host, defaulting to github.com.
+func (a Account) Hostname() string {
+	if a.Host == "" {
+		return DefaultHost
+	}
+	return a.Host
+}
+
+// RemoteRule binds repositories whose remote URL matches Pattern to an
+// account. Patterns are written as host:owner/repo, where * matches any
+// run of characters, e.g. github.com:acme-corp/*.
+type RemoteRule struct {
+	Pattern string `yaml:"pattern"`
+	Account string `yaml:"account"`
 }
 
 type Config struct {
+	// Version is the schema version of the file; see CurrentVersion.
+	Version  int                `yaml:"version"`
 	Accounts map[string]Account `yaml:"accounts"`
+	// Profiles are partial accounts that accounts extend. They cannot be
+	// switched to themselves.
+	Profiles map[string]Account `yaml:"profiles,omitempty"`
+	// Directories binds directory trees to accounts. Keys may start with ~/.
+	Directories map[string]string `yaml:"directories,omitempty"`
+	// Remotes binds repositories to accounts by remote URL. The first
+	// matching rule wins.
+	Remotes []RemoteRule `yaml:"remotes,omitempty"`
+	// Includes lists read-only overlay files, such as a team config kept in
+	// a dotfiles repository, merged under this one.
+	Includes []string `yaml:"includes,omitempty"`
+
+	layers  *layers
+	upgrade *upgrade
 }
 
+// EnvConfig names the environment variable that overrides the config path.
+const EnvConfig = "GITHUB_SWITCH_CONFIG"
+
 var configPath string
 
 func init() {
+	path, err := DefaultPath()
+	if err != nil {
+		path = "config.yaml"
+	}
+	configPath = path
+}
+
+// DefaultPath returns $XDG_CONFIG_HOME/github-switch/config.yaml.
+func DefaultPath() (string, error) {
+	dir, err := xdg.ConfigHome()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, xdg.App, "config.yaml"), nil
+}
+
+// LegacyPath returns ~/.github-switch.yaml, where older releases kept the
+// config.
+func LegacyPath() (string, error) {
 	home, err := os.UserHomeDir()
 	if err != nil {
-		configPath = ".github-switch.yaml"
-		return
+		return "", fmt.Errorf("failed to get home directory: %w", err)
 	}
-	configPath = filepath.Join(home, ".github-switch.yaml")
+	return filepath.Join(home, ".github-switch.yaml"), nil
+}
+
+// SetPath points the package at a different config file.
+func SetPath(path string) {
+	configPath = path
+}
+
+// Init selects the config file: path if non-empty, then $GITHUB_SWITCH_CONFIG,
+// then the default location. When the default is used and only a legacy
+// config exists, it is moved there first and its old path is returned.
+func Init(path string) (string, error) {
+	if path == "" {
+		path = os.Getenv(EnvConfig)
+	}
+	if path != "" {
+		configPath = path
+		return "", nil
+	}
+
+	path, err := DefaultPath()
+	if err != nil {
+		return "", err
+	}
+	configPath = path
+
+	legacy, err := LegacyPath()
+	if err != nil {
+		return "", err
+	}
+	moved, err := xdg.MigrateLegacy(legacy, path)
+	if err != nil || !moved {
+		return "", err
+	}
+	return legacy, nil
 }
 
 func GetConfigPath() string {
 	return configPath
 }
 
+// Load reads the user's config and merges the read-only overlays under it.
 func Load() (*Config, error) {
+	cfg, err := loadPersonal()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cfg.applyOverlays(); err != nil {
+		return nil, err
+	}
+
+	if err := cfg.CheckExtends(); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+func loadPersonal() (*Config, error) {
 	data, err := os.ReadFile(configPath)
 	if err != nil {
 		if errors.Is(err, os.ErrNotExist) {
-			return &Config{Accounts: make(map[string]Account)}, nil
+			return &Config{Version: CurrentVersion, Accounts: make(map[string]Account)}, nil
 		}
 		return nil, fmt.Errorf("failed to read config: %w", err)
 	}
 
+	doc, from, err := migrate(data)
+	if err != nil {
+		return nil, err
+	}
+
 	var cfg Config
-	if err := yaml.Unmarshal(data, &cfg); err != nil {
+	if doc != nil {
+		cfg.upgrade = &upgrade{original: data, from: from}
+		err = doc.Decode(&cfg)
+	} else {
+		err = yaml.Unmarshal(data, &cfg)
+	}
+	if err != nil {
 		return nil, fmt.Errorf("failed to parse config: %w", err)
 	}
 
@@ -57,12 +209,27 @@ func Load() (*Config, error) {
 	return &cfg, nil
 }
 
+// Save writes the user's config. Entries that come unchanged from an
+// overlay are left out. A config written by an older release is backed up
+// first.
 func (c *Config) Save() error {
-	data, err := yaml.Marshal(c)
+	c.Version = CurrentVersion
+	data, err := yaml.Marshal(c.personal())
 	if err != nil {
 		return fmt.Errorf("failed to marshal config: %w", err)
 	}
 
+	if err := os.MkdirAll(filepath.Dir(configPath), 0o700); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+
+	if c.upgrade != nil {
+		if err := c.upgrade.backup(); err != nil {
+			return err
+		}
+		c.upgrade = nil
+	}
+
 	if err := os.WriteFile(configPath, data, 0o600); err != nil {
 		return fmt.Errorf("failed to write config: %w", err)
 	}
@@ -70,9 +237,16 @@ func (c *Config) Save() error {
 	return nil
 }
 
+// GetAccount returns the named account with the fields it inherits through
+// Extends filled in. Use Accounts directly for the fields as written.
 func (c *Config) GetAccount(name string) (Account, bool) {
 	acc, ok := c.Accounts[name]
-	return acc, ok
+	if !ok {
+		return Account{}, false
+	}
+	// Load rejects broken chains, so an error only leaves fields unfilled.
+	resolved, _ := c.resolve(acc, []string{"account " + name})
+	return resolved, true
 }
 
 func (c *Config) AddAccount(name string, acc Account) {
@@ -87,6 +261,57 @@ func (c *Config) RemoveAccount(name string) bool {
 	return true
 }
 
+// RenameAccount renames an account and updates the directory and remote
+// rules that refer to it.
+func (c *Config) RenameAccount(oldName, newName string) error {
+	acc, ok := c.Accounts[oldName]
+	if !ok {
+		return fmt.Errorf("account '%s' not found", oldName)
+	}
+	if _, exists := c.Accounts[newName]; exists {
+		return fmt.Errorf("account '%s' already exists", newName)
+	}
+
+	delete(c.Accounts, oldName)
+	c.Accounts[newName] = acc
+
+	for dir, name := range c.Directories {
+		if name == oldName {
+			c.Directories[dir] = newName
+		}
+	}
+	for i := range c.Remotes {
+		if c.Remotes[i].Account == oldName {
+			c.Remotes[i].Account = newName
+		}
+	}
+	// A profile of the old name is what extends referred to all along.
+	if _, shadowed := c.Profiles[oldName]; shadowed {
+		return nil
+	}
+	for _, accounts := range []map[string]Account{c.Accounts, c.Profiles} {
+		for name, acc := range accounts {
+			if acc.Extends == oldName {
+				acc.Extends = newName
+				accounts[name] = acc
+			}
+		}
+	}
+	return nil
+}
+
+// FilterAccounts returns the names of the accounts carrying every one of
+// tags, in name order.
+func (c *Config) FilterAccounts(tags []string) []string {
+	var names []string
+	for _, name := range c.ListAccounts() {
+		if acc, _ := c.GetAccount(name); acc.HasTags(tags...) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
 func (c *Config) ListAccounts() []string {
 	names := make([]string, 0, len(c.Accounts))
 	for name := range c.Accounts {
@@ -89,6 +89,41 @@ func TestRemoveAccount(t *testing.T) {
 	}
 }
 
+func TestRenameAccount(t *testing.T) {
+	cfg := &Config{
+		Accounts: map[string]Account{
+			"work":     {Email: "work@example.com"},
+			"personal": {},
+		},
+		Directories: map[string]string{"~/work": "work", "~/src": "personal"},
+		Remotes:     []RemoteRule{{Pattern: "github.com:acme/*", Account: "work"}},
+	}
+
+	if err := cfg.RenameAccount("work", "acme"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, ok := cfg.GetAccount("work"); ok {
+		t.Error("expected account 'work' to be gone")
+	}
+	if acc, ok := cfg.GetAccount("acme"); !ok || acc.Email != "work@example.com" {
+		t.Errorf("expected account 'acme' to keep its fields, got %+v", acc)
+	}
+	if cfg.Directories["~/work"] != "acme" || cfg.Directories["~/src"] != "personal" {
+		t.Errorf("unexpected directories: %v", cfg.Directories)
+	}
+	if cfg.Remotes[0].Account != "acme" {
+		t.Errorf("expected remote rule to follow the rename, got %q", cfg.Remotes[0].Account)
+	}
+
+	if err := cfg.RenameAccount("acme", "personal"); err == nil {
+		t.Error("expected an error when renaming onto an existing account")
+	}
+	if err := cfg.RenameAccount("missing", "other"); err == nil {
+		t.Error("expected an error when renaming an unknown account")
+	}
+}
+
 func TestLoadNonexistentConfig(t *testing.T) {
 	tmpDir := t.TempDir()
 	configPath = filepath.Join(tmpDir, "nonexistent.yaml")
@@ -127,3 +162,61 @@ func TestConfigPermissions(t *testing.T) {
 		t.Errorf("expected permissions 0600, got %o", perm)
 	}
 }
+
+func TestInitPrecedence(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, "xdg"))
+	t.Setenv(EnvConfig, filepath.Join(home, "env.yaml"))
+
+	if _, err := Init(filepath.Join(home, "flag.yaml")); err != nil {
+		t.Fatal(err)
+	}
+	if got := GetConfigPath(); got != filepath.Join(home, "flag.yaml") {
+		t.Errorf("expected explicit path to win, got %s", got)
+	}
+
+	if _, err := Init(""); err != nil {
+		t.Fatal(err)
+	}
+	if got := GetConfigPath(); got != filepath.Join(home, "env.yaml") {
+		t.Errorf("expected %s to win over the default, got %s", EnvConfig, got)
+	}
+
+	t.Setenv(EnvConfig, "")
+	if _, err := Init(""); err != nil {
+		t.Fatal(err)
+	}
+	if got := GetConfigPath(); got != filepath.Join(home, "xdg", "github-switch", "config.yaml") {
+		t.Errorf("expected XDG default, got %s", got)
+	}
+}
+
+func TestInitMigratesLegacyConfig(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", "")
+	t.Setenv(EnvConfig, "")
+
+	legacy := filepath.Join(home, ".github-switch.yaml")
+	content := "accounts:\n  work:\n    ssh_key: id_work\n    name: Work\n    email: work@example.com\n"
+	if err := os.WriteFile(legacy, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	moved, err := Init("")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if moved != legacy {
+		t.Errorf("expected %s to be reported as moved, got '%s'", legacy, moved)
+	}
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("failed to load migrated config: %v", err)
+	}
+	if _, ok := cfg.GetAccount("work"); !ok {
+		t.Error("expected account 'work' in the migrated config")
+	}
+}
@@ -0,0 +1,117 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+
+	"gopkg.in/yaml.v3"
+)
+
+// CurrentVersion is the config schema version this build reads and writes.
+// Files without a version key are version 0.
+const CurrentVersion = 1
+
+// ErrNewerVersion is returned when the config was written by a newer
+// github-switch, whose fields this build could silently drop.
+var ErrNewerVersion = errors.New("config was written by a newer version of github-switch")
+
+// migration upgrades the raw document of a config from version from to
+// from+1. It works on the YAML tree, before the document is decoded, so
+// that it can rename or reshape keys that Config no longer has.
+type migration struct {
+	from        int
+	description string
+	apply       func(doc *yaml.Node) error
+}
+
+// migrations must be ordered by from and contiguous, starting at 0.
+var migrations = []migration{
+	{
+		from:        0,
+		description: "add schema version",
+		apply:       func(doc *yaml.Node) error { return nil },
+	},
+}
+
+// migrate brings data up to CurrentVersion. It returns the upgraded document
+// and the version the file had, or nil when no migration was needed.
+func migrate(data []byte) (*yaml.Node, int, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, 0, fmt.Errorf("failed to parse config: %w", err)
+	}
+	if len(doc.Content) == 0 {
+		return nil, CurrentVersion, nil
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, 0, fmt.Errorf("failed to parse config: top level must be a mapping")
+	}
+
+	version := 0
+	if node := lookup(root, "version"); node != nil {
+		v, err := strconv.Atoi(node.Value)
+		if err != nil || v < 0 {
+			return nil, 0, fmt.Errorf("invalid config version '%s' (line %d)", node.Value, node.Line)
+		}
+		version = v
+	}
+
+	if version > CurrentVersion {
+		return nil, version, fmt.Errorf("%w: file is version %d, this build supports up to %d; upgrade github-switch",
+			ErrNewerVersion, version, CurrentVersion)
+	}
+	if version == CurrentVersion {
+		return nil, version, nil
+	}
+
+	for _, m := range migrations[version:] {
+		if err := m.apply(root); err != nil {
+			return nil, version, fmt.Errorf("failed to migrate config from version %d (%s): %w", m.from, m.description, err)
+		}
+	}
+	setVersion(root, CurrentVersion)
+
+	return &doc, version, nil
+}
+
+// upgrade records that a config was migrated when it was loaded. The file
+// itself is only rewritten by Save, which commands call under the lock.
+type upgrade struct {
+	original []byte
+	from     int
+}
+
+// backup keeps the original file as <config>.v<from>.bak.
+func (u *upgrade) backup() error {
+	path := fmt.Sprintf("%s.v%d.bak", configPath, u.from)
+	if err := os.WriteFile(path, u.original, 0o600); err != nil {
+		return fmt.Errorf("failed to back up config: %w", err)
+	}
+	return nil
+}
+
+func lookup(mapping *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return mapping.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// setVersion writes the version key, placing it first when it is new.
+func setVersion(root *yaml.Node, version int) {
+	value := strconv.Itoa(version)
+	if node := lookup(root, "version"); node != nil {
+		node.Value = value
+		node.Tag = "!!int"
+		return
+	}
+	root.Content = append([]*yaml.Node{
+		{Kind: yaml.ScalarNode, Tag: "!!str", Value: "version"},
+		{Kind: yaml.ScalarNode, Tag: "!!int", Value: value},
+	}, root.Content...)
+}
@@ -0,0 +1,92 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSaveMigratesUnversionedConfig(t *testing.T) {
+	configPath = filepath.Join(t.TempDir(), "config.yaml")
+	original := "accounts:\n  work:\n    ssh_key: id_work\n    name: Work\n    email: work@example.com\n"
+	if err := os.WriteFile(configPath, []byte(original), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Version != CurrentVersion {
+		t.Errorf("expected version %d, got %d", CurrentVersion, cfg.Version)
+	}
+	if _, ok := cfg.GetAccount("work"); !ok {
+		t.Error("expected account 'work' to survive the migration")
+	}
+
+	// Loading, as read-only commands do without the lock, writes nothing.
+	if data, _ := os.ReadFile(configPath); string(data) != original {
+		t.Errorf("expected Load to leave the file alone:\n%s", data)
+	}
+	if _, err := os.Stat(configPath + ".v0.bak"); !os.IsNotExist(err) {
+		t.Error("expected no backup before the config is saved")
+	}
+
+	if err := cfg.Save(); err != nil {
+		t.Fatalf("failed to save config: %v", err)
+	}
+
+	backup, err := os.ReadFile(configPath + ".v0.bak")
+	if err != nil {
+		t.Fatalf("expected a backup of the original file: %v", err)
+	}
+	if string(backup) != original {
+		t.Errorf("expected backup to match the original:\n%s", backup)
+	}
+
+	migrated, err := os.ReadFile(configPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(migrated), "version: 1\n") {
+		t.Errorf("expected migrated file to start with the version:\n%s", migrated)
+	}
+}
+
+func TestLoadRefusesNewerConfig(t *testing.T) {
+	configPath = filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(configPath, []byte("version: 99\naccounts: {}\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Load(); !errors.Is(err, ErrNewerVersion) {
+		t.Errorf("expected ErrNewerVersion, got %v", err)
+	}
+}
+
+func TestLoadCurrentConfigIsNotRewritten(t *testing.T) {
+	configPath = filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(configPath, []byte("version: 1\naccounts: {}\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Load(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := os.Stat(configPath + ".v1.bak"); !os.IsNotExist(err) {
+		t.Error("expected no backup for an up-to-date config")
+	}
+}
+
+func TestMigrationsAreContiguous(t *testing.T) {
+	if len(migrations) != CurrentVersion {
+		t.Fatalf("expected %d migrations, got %d", CurrentVersion, len(migrations))
+	}
+	for i, m := range migrations {
+		if m.from != i {
+			t.Errorf("migration %d upgrades from version %d", i, m.from)
+		}
+	}
+}
@@ -0,0 +1,295 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// systemDir holds overlays installed for every user of the machine.
+var systemDir = "/etc/github-switch.d"
+
+// Source is a config file contributing to the loaded configuration.
+type Source struct {
+	Path string
+	// ReadOnly is set for overlays, which are never written.
+	ReadOnly bool
+	// Missing is set for an include that does not exist.
+	Missing  bool
+	Accounts []string
+	Profiles []string
+}
+
+// layers records where the merged configuration came from, so that Save can
+// write back only the user's own entries.
+type layers struct {
+	// sources lists the files in order of precedence, highest first.
+	sources []Source
+	// overlay is the merge of the overlays alone.
+	overlay *Config
+	// personal holds the entries present in the user's file when loaded,
+	// as "accounts/<name>", "profiles/<name>" and "directories/<dir>".
+	personal map[string]bool
+}
+
+// applyOverlays merges the overlays under c, which holds the user's config:
+// the files in the system directory in name order, then the files listed
+// under includes in order, each overriding the ones before. Accounts,
+// profiles and directories are replaced whole by name; remote rules of the
+// user's config are tried first, then those of the includes from last to
+// first, then those of the system directory.
+func (c *Config) applyOverlays() error {
+	paths, err := overlayPaths(c.Includes)
+	if err != nil {
+		return err
+	}
+
+	l := &layers{
+		overlay:  &Config{Accounts: make(map[string]Account)},
+		personal: make(map[string]bool),
+	}
+	for name := range c.Accounts {
+		l.personal["accounts/"+name] = true
+	}
+	for name := range c.Profiles {
+		l.personal["profiles/"+name] = true
+	}
+	for dir := range c.Directories {
+		l.personal["directories/"+dir] = true
+	}
+
+	sources := []Source{{Path: configPath, Accounts: accountNames(c.Accounts), Profiles: accountNames(c.Profiles)}}
+	for _, path := range paths {
+		overlay, err := loadOverlay(path)
+		if errors.Is(err, os.ErrNotExist) {
+			sources = append(sources, Source{Path: path, ReadOnly: true, Missing: true})
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		sources = append(sources, Source{
+			Path:     path,
+			ReadOnly: true,
+			Accounts: accountNames(overlay.Accounts),
+			Profiles: accountNames(overlay.Profiles),
+		})
+		l.overlay.mergeOver(overlay)
+	}
+
+	// Higher precedence first, matching how sources are reported.
+	for i, j := 1, len(sources)-1; i < j; i, j = i+1, j-1 {
+		sources[i], sources[j] = sources[j], sources[i]
+	}
+	l.sources = sources
+
+	merged := &Config{}
+	merged.mergeOver(l.overlay)
+	merged.mergeOver(c)
+	c.Accounts = merged.Accounts
+	c.Profiles = merged.Profiles
+	c.Directories = merged.Directories
+	c.Remotes = merged.Remotes
+	c.layers = l
+	return nil
+}
+
+// mergeOver lays other on top of c.
+func (c *Config) mergeOver(other *Config) {
+	if c.Accounts == nil {
+		c.Accounts = make(map[string]Account)
+	}
+	for name, acc := range other.Accounts {
+		c.Accounts[name] = acc
+	}
+	if len(other.Profiles) > 0 && c.Profiles == nil {
+		c.Profiles = make(map[string]Account)
+	}
+	for name, profile := range other.Profiles {
+		c.Profiles[name] = profile
+	}
+	if len(other.Directories) > 0 && c.Directories == nil {
+		c.Directories = make(map[string]string)
+	}
+	for dir, name := range other.Directories {
+		c.Directories[dir] = name
+	}
+	c.Remotes = append(append([]RemoteRule(nil), other.Remotes...), c.Remotes...)
+}
+
+// personal returns the part of c that belongs in the user's file: entries
+// it had when loaded, and anything added or changed since.
+func (c *Config) personal() *Config {
+	if c.layers == nil {
+		return c
+	}
+	l := c.layers
+	out := &Config{
+		Version:  c.Version,
+		Includes: c.Includes,
+		Accounts: make(map[string]Account),
+	}
+
+	for name, acc := range c.Accounts {
+		if base, ok := l.overlay.Accounts[name]; !ok || !base.Equal(acc) || l.personal["accounts/"+name] {
+			out.Accounts[name] = acc
+		}
+	}
+	for name, profile := range c.Profiles {
+		if base, ok := l.overlay.Profiles[name]; !ok || !base.Equal(profile) || l.personal["profiles/"+name] {
+			if out.Profiles == nil {
+				out.Profiles = make(map[string]Account)
+			}
+			out.Profiles[name] = profile
+		}
+	}
+	for dir, name := range c.Directories {
+		if base, ok := l.overlay.Directories[dir]; !ok || base != name || l.personal["directories/"+dir] {
+			if out.Directories == nil {
+				out.Directories = make(map[string]string)
+			}
+			out.Directories[dir] = name
+		}
+	}
+
+	// Overlay rules come last in the merged list; drop one copy of each.
+	inherited := make(map[RemoteRule]int)
+	for _, rule := range l.overlay.Remotes {
+		inherited[rule]++
+	}
+	for i := len(c.Remotes) - 1; i >= 0; i-- {
+		rule := c.Remotes[i]
+		if inherited[rule] > 0 {
+			inherited[rule]--
+			continue
+		}
+		out.Remotes = append([]RemoteRule{rule}, out.Remotes...)
+	}
+	return out
+}
+
+// Sources lists the files the configuration was loaded from, highest
+// precedence first.
+func (c *Config) Sources() []Source {
+	if c.layers == nil {
+		return []Source{{Path: configPath, Accounts: c.ListAccounts(), Profiles: accountNames(c.Profiles)}}
+	}
+	return c.layers.sources
+}
+
+// Definitions lists the files that define an account, highest precedence
+// first. The first is the one in effect.
+func (c *Config) Definitions(name string) []string {
+	var paths []string
+	for _, src := range c.Sources() {
+		for _, account := range src.Accounts {
+			if account == name {
+				paths = append(paths, src.Path)
+			}
+		}
+	}
+	return paths
+}
+
+// LoadOverlays merges the overlays a config with the given includes is laid
+// over, skipping missing files.
+func LoadOverlays(includes []string) (*Config, error) {
+	paths, err := overlayPaths(includes)
+	if err != nil {
+		return nil, err
+	}
+
+	merged := &Config{Accounts: make(map[string]Account)}
+	for _, path := range paths {
+		overlay, err := loadOverlay(path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		merged.mergeOver(overlay)
+	}
+	return merged, nil
+}
+
+// overlayPaths lists the overlays in increasing order of precedence.
+func overlayPaths(includes []string) ([]string, error) {
+	paths, err := filepath.Glob(filepath.Join(systemDir, "*.yaml"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s: %w", systemDir, err)
+	}
+	sort.Strings(paths)
+	for _, include := range includes {
+		paths = append(paths, includePath(include))
+	}
+	return paths, nil
+}
+
+// ReadOnly reports whether an account comes only from an overlay, so that
+// changes to it cannot be saved as such: it can be overridden but not
+// removed or renamed.
+func (c *Config) ReadOnly(name string) bool {
+	if c.layers == nil {
+		return false
+	}
+	_, inOverlay := c.layers.overlay.Accounts[name]
+	return inOverlay && !c.layers.personal["accounts/"+name]
+}
+
+// includePath resolves an includes entry: ~/ is expanded and relative paths
+// are taken from the directory of the user's config.
+func includePath(include string) string {
+	if rest, ok := strings.CutPrefix(include, "~/"); ok {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, rest)
+		}
+	}
+	if filepath.IsAbs(include) {
+		return include
+	}
+	return filepath.Join(filepath.Dir(configPath), include)
+}
+
+// loadOverlay reads an overlay. Older schemas are migrated in memory only,
+// since overlays are never written.
+func loadOverlay(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	doc, _, err := migrate(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	var overlay Config
+	if doc != nil {
+		err = doc.Decode(&overlay)
+	} else {
+		err = yaml.Unmarshal(data, &overlay)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return &overlay, nil
+}
+
+func accountNames(m map[string]Account) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
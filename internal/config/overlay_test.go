@@ -0,0 +1,150 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func setupOverlays(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+
+	oldSystemDir := systemDir
+	systemDir = filepath.Join(dir, "system")
+	t.Cleanup(func() { systemDir = oldSystemDir })
+	configPath = filepath.Join(dir, "config.yaml")
+
+	writeFile(t, filepath.Join(systemDir, "10-org.yaml"), `version: 1
+profiles:
+  org: {name: Org Person, host: github.acme.com}
+accounts:
+  acme: {extends: org, ssh_key: id_acme, email: me@acme.example}
+  shared: {ssh_key: id_org, name: Org, email: org@acme.example}
+remotes:
+  - {pattern: "github.acme.com:*", account: acme}
+`)
+	writeFile(t, filepath.Join(systemDir, "20-team.yaml"), `accounts:
+  shared: {ssh_key: id_team, name: Team, email: team@acme.example}
+directories:
+  ~/acme: acme
+`)
+	writeFile(t, filepath.Join(dir, "team.yaml"), `version: 1
+accounts:
+  team: {extends: org, ssh_key: id_t, email: t@acme.example}
+remotes:
+  - {pattern: "github.com:acme/*", account: team}
+`)
+	return dir
+}
+
+func TestLoadMergesOverlays(t *testing.T) {
+	dir := setupOverlays(t)
+	writeFile(t, configPath, `version: 1
+includes: [team.yaml, missing.yaml]
+accounts:
+  personal: {ssh_key: id_me, name: Me, email: me@home.example}
+  team: {extends: org, ssh_key: id_mine, email: mine@acme.example}
+remotes:
+  - {pattern: "github.com:me/*", account: personal}
+`)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if acc, _ := cfg.GetAccount("acme"); acc.Name != "Org Person" || acc.Host != "github.acme.com" {
+		t.Errorf("expected acme to inherit from the org profile, got %+v", acc)
+	}
+	if acc, _ := cfg.GetAccount("shared"); acc.SSHKey != "id_team" {
+		t.Errorf("expected the later system file to win, got %+v", acc)
+	}
+	if acc, _ := cfg.GetAccount("team"); acc.SSHKey != "id_mine" {
+		t.Errorf("expected the user's config to win, got %+v", acc)
+	}
+	if cfg.Directories["~/acme"] != "acme" {
+		t.Errorf("expected directories from overlays, got %v", cfg.Directories)
+	}
+
+	var patterns []string
+	for _, rule := range cfg.Remotes {
+		patterns = append(patterns, rule.Pattern)
+	}
+	if got := strings.Join(patterns, " "); got != "github.com:me/* github.com:acme/* github.acme.com:*" {
+		t.Errorf("unexpected remote rule order: %s", got)
+	}
+
+	if !cfg.ReadOnly("acme") || cfg.ReadOnly("team") || cfg.ReadOnly("personal") {
+		t.Error("expected only accounts defined solely by overlays to be read-only")
+	}
+	if defs := cfg.Definitions("team"); len(defs) != 2 || defs[0] != configPath || defs[1] != filepath.Join(dir, "team.yaml") {
+		t.Errorf("unexpected definitions of team: %v", defs)
+	}
+
+	sources := cfg.Sources()
+	if len(sources) != 5 || sources[0].Path != configPath || !sources[1].Missing || sources[4].Path != filepath.Join(systemDir, "10-org.yaml") {
+		t.Errorf("unexpected sources: %+v", sources)
+	}
+}
+
+func TestSaveWritesOnlyPersonalEntries(t *testing.T) {
+	setupOverlays(t)
+	writeFile(t, configPath, "version: 1\naccounts:\n  personal: {ssh_key: id_me, name: Me, email: me@home.example}\n")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	override := cfg.Accounts["acme"]
+	override.Email = "other@acme.example"
+	cfg.AddAccount("acme", override)
+	cfg.Directories["~/me"] = "personal"
+
+	if err := cfg.Save(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	saved, err := loadPersonal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if names := strings.Join(saved.ListAccounts(), " "); names != "acme personal" {
+		t.Errorf("expected only the override and the user's account to be saved, got %s", names)
+	}
+	if saved.Accounts["acme"].Email != "other@acme.example" {
+		t.Errorf("expected the override to be saved, got %+v", saved.Accounts["acme"])
+	}
+	if len(saved.Directories) != 1 || len(saved.Remotes) != 0 || len(saved.Profiles) != 0 {
+		t.Errorf("expected overlay rules and profiles to stay out of the file: %+v", saved)
+	}
+}
+
+func TestValidateResolvesAgainstBase(t *testing.T) {
+	setupOverlays(t)
+	base, err := LoadOverlays(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := "accounts:\n  mine:\n    extends: org\n    ssh_key: id_mine\n    email: mine@acme.example\ndirectories:\n  ~/src: shared\n"
+	issues, err := Validate([]byte(data), ValidateOptions{Base: base})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(issues) != 0 {
+		t.Errorf("expected references to overlays to validate, got %v", issues)
+	}
+}
@@ -0,0 +1,103 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// base looks up the profile or account an Extends field names. Profiles
+// take precedence over accounts of the same name, so an account may extend
+// a profile named after it.
+func (c *Config) base(name string) (Account, string, bool) {
+	if acc, ok := c.Profiles[name]; ok {
+		return acc, "profile " + name, true
+	}
+	acc, ok := c.Accounts[name]
+	return acc, "account " + name, ok
+}
+
+// resolve fills in the fields acc inherits. chain holds the accounts and
+// profiles visited so far, as "account <name>" or "profile <name>", to
+// detect cycles.
+func (c *Config) resolve(acc Account, chain []string) (Account, error) {
+	if acc.Extends == "" {
+		return acc, nil
+	}
+
+	parent, id, ok := c.base(acc.Extends)
+	if !ok {
+		return acc, fmt.Errorf("extends unknown profile '%s'", acc.Extends)
+	}
+
+	for _, visited := range chain {
+		if visited == id {
+			return acc, fmt.Errorf("inheritance cycle %s", strings.Join(append(chain, id), " -> "))
+		}
+	}
+
+	parent, err := c.resolve(parent, append(chain[:len(chain):len(chain)], id))
+	if err != nil {
+		return acc, err
+	}
+	return inherit(acc, parent), nil
+}
+
+// inherit fills the empty fields of acc from parent and adds the parent's
+// tags to its own. Tokens are never inherited.
+func inherit(acc, parent Account) Account {
+	if acc.SSHKey == "" {
+		acc.SSHKey = parent.SSHKey
+	}
+	if acc.Name == "" {
+		acc.Name = parent.Name
+	}
+	if acc.Email == "" {
+		acc.Email = parent.Email
+	}
+	if acc.Host == "" {
+		acc.Host = parent.Host
+	}
+	if acc.User == "" {
+		acc.User = parent.User
+	}
+	tags := slices.Clone(parent.Tags)
+	for _, tag := range acc.Tags {
+		if !slices.Contains(tags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+	acc.Tags = tags
+	return acc
+}
+
+// CheckExtends reports the first account or profile whose inheritance
+// chain is broken.
+func (c *Config) CheckExtends() error {
+	for _, section := range []struct {
+		kind     string
+		accounts map[string]Account
+	}{{"account", c.Accounts}, {"profile", c.Profiles}} {
+		for _, name := range accountNames(section.accounts) {
+			if _, err := c.resolve(section.accounts[name], []string{section.kind + " " + name}); err != nil {
+				return fmt.Errorf("%s '%s': %w", section.kind, name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// ExtendedBy lists the accounts and profiles that extend name directly.
+func (c *Config) ExtendedBy(name string) []string {
+	var names []string
+	for _, accounts := range []map[string]Account{c.Accounts, c.Profiles} {
+		for other, acc := range accounts {
+			if acc.Extends == name {
+				names = append(names, other)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,113 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGetAccountInherits(t *testing.T) {
+	cfg := &Config{
+		Profiles: map[string]Account{
+			"base":      {Name: "Me", Host: "github.acme.com"},
+			"work-base": {Extends: "base", Email: "me@work.example", Token: "secret"},
+		},
+		Accounts: map[string]Account{
+			"client-a": {Extends: "work-base", SSHKey: "id_a"},
+			"client-b": {Extends: "client-a", SSHKey: "id_b", Email: "me@b.example"},
+		},
+	}
+
+	a, ok := cfg.GetAccount("client-a")
+	if !ok {
+		t.Fatal("expected account 'client-a'")
+	}
+	if a.Name != "Me" || a.Email != "me@work.example" || a.SSHKey != "id_a" || a.Host != "github.acme.com" {
+		t.Errorf("unexpected resolved account: %+v", a)
+	}
+	if a.Token != "" {
+		t.Error("expected tokens not to be inherited")
+	}
+
+	b, _ := cfg.GetAccount("client-b")
+	if b.Name != "Me" || b.Email != "me@b.example" || b.SSHKey != "id_b" {
+		t.Errorf("expected overrides to win over inherited fields: %+v", b)
+	}
+
+	if cfg.Accounts["client-a"].Name != "" {
+		t.Error("expected the raw account to be left untouched")
+	}
+	if _, ok := cfg.GetAccount("work-base"); ok {
+		t.Error("expected profiles not to be accounts")
+	}
+}
+
+func TestAccountMayExtendProfileOfSameName(t *testing.T) {
+	cfg := &Config{
+		Profiles: map[string]Account{"acme": {Name: "Me"}},
+		Accounts: map[string]Account{"acme": {Extends: "acme", SSHKey: "id_acme"}},
+	}
+	if err := cfg.CheckExtends(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if acc, _ := cfg.GetAccount("acme"); acc.Name != "Me" {
+		t.Errorf("expected the profile to be inherited, got %+v", acc)
+	}
+}
+
+func TestLoadRejectsBrokenInheritance(t *testing.T) {
+	tests := map[string]string{
+		"inheritance cycle account a -> account b -> account a": "accounts:\n  a: {extends: b}\n  b: {extends: a}\n",
+		"extends unknown profile 'missing'":                     "accounts:\n  a: {extends: missing}\n",
+	}
+
+	for expected, content := range tests {
+		configPath = filepath.Join(t.TempDir(), "config.yaml")
+		if err := os.WriteFile(configPath, []byte("version: 1\n"+content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		_, err := Load()
+		if err == nil || !strings.Contains(err.Error(), expected) {
+			t.Errorf("expected an error containing %q, got %v", expected, err)
+		}
+	}
+}
+
+func TestRenameAccountUpdatesExtends(t *testing.T) {
+	cfg := &Config{
+		Profiles: map[string]Account{"p": {Extends: "base"}},
+		Accounts: map[string]Account{"base": {Name: "Me"}, "child": {Extends: "base"}},
+	}
+	if err := cfg.RenameAccount("base", "root"); err != nil {
+		t.Fatal(err)
+	}
+	if got := cfg.ExtendedBy("root"); strings.Join(got, ",") != "child,p" {
+		t.Errorf("expected extends to follow the rename, got %v", got)
+	}
+}
+
+func TestTagsAreInheritedAndFiltered(t *testing.T) {
+	cfg := &Config{
+		Profiles: map[string]Account{"work-base": {Tags: []string{"work"}}},
+		Accounts: map[string]Account{
+			"client-a": {Extends: "work-base", Tags: []string{"client", "work"}},
+			"client-b": {Extends: "work-base", Tags: []string{"client"}},
+			"oss":      {Tags: []string{"oss"}},
+		},
+	}
+
+	if acc, _ := cfg.GetAccount("client-b"); strings.Join(acc.Tags, ",") != "work,client" {
+		t.Errorf("expected inherited tags to be merged, got %v", acc.Tags)
+	}
+	if acc, _ := cfg.GetAccount("client-a"); strings.Join(acc.Tags, ",") != "work,client" {
+		t.Errorf("expected duplicate tags to be dropped, got %v", acc.Tags)
+	}
+
+	if got := strings.Join(cfg.FilterAccounts([]string{"work", "client"}), ","); got != "client-a,client-b" {
+		t.Errorf("unexpected filter result: %s", got)
+	}
+	if got := strings.Join(cfg.FilterAccounts(nil), ","); got != "client-a,client-b,oss" {
+		t.Errorf("expected no tags to match every account, got %s", got)
+	}
+}
@@ -0,0 +1,360 @@
+package config
+
+import (
+	"fmt"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// Issue is a validation finding, positioned at the YAML node it concerns.
+type Issue struct {
+	Line     int    `json:"line" yaml:"line"`
+	Column   int    `json:"column" yaml:"column"`
+	Path     string `json:"path" yaml:"path"`
+	Severity string `json:"severity" yaml:"severity"`
+	Message  string `json:"message" yaml:"message"`
+}
+
+func (i Issue) String() string {
+	return fmt.Sprintf("%d:%d: %s: %s: %s", i.Line, i.Column, i.Severity, i.Path, i.Message)
+}
+
+// ValidateOptions tunes Validate.
+type ValidateOptions struct {
+	// SSHDir, when set, is checked for the key file of every account.
+	SSHDir string
+	// Base holds the overlays the file is merged over (see LoadOverlays),
+	// whose accounts and profiles it may refer to.
+	Base *Config
+}
+
+var (
+	topLevelKeys   = []string{"version", "accounts", "profiles", "directories", "remotes", "includes"}
+	accountKeys    = []string{"ssh_key", "name", "email", "host", "user", "token", "extends", "tags"}
+	profileKeys    = []string{"ssh_key", "name", "email", "host", "user", "extends", "tags"}
+	remoteRuleKeys = []string{"pattern", "account"}
+)
+
+type validator struct {
+	opts     ValidateOptions
+	issues   []Issue
+	accounts map[string]bool
+	// cfg is the decoded file, used to resolve inherited fields.
+	cfg Config
+}
+
+// Validate checks a config file's content against the schema and returns
+// every issue found, sorted by position. A YAML syntax error is returned as
+// an error since no tree is available to validate.
+func Validate(data []byte, opts ValidateOptions) ([]Issue, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse config: %w", err)
+	}
+
+	v := &validator{opts: opts, accounts: make(map[string]bool)}
+	if len(doc.Content) == 0 {
+		return nil, nil
+	}
+
+	root := doc.Content[0]
+	if !v.expectKind(root, yaml.MappingNode, "config") {
+		return v.issues, nil
+	}
+	v.checkKeys(root, "", topLevelKeys)
+
+	// A partial decode is enough to resolve inheritance; type errors are
+	// reported against the nodes below.
+	var own Config
+	_ = root.Decode(&own)
+	if opts.Base != nil {
+		v.cfg.mergeOver(opts.Base)
+		for name := range opts.Base.Accounts {
+			v.accounts[name] = true
+		}
+	}
+	v.cfg.mergeOver(&own)
+
+	if node := lookup(root, "version"); node != nil {
+		v.validateVersion(node)
+	}
+
+	// Accounts are collected first so that rules can refer to them.
+	if node := lookup(root, "accounts"); node != nil {
+		v.validateAccounts(node)
+	} else {
+		v.add(root, "accounts", SeverityError, "missing required key")
+	}
+	if node := lookup(root, "profiles"); node != nil {
+		v.validateProfiles(node)
+	}
+	if node := lookup(root, "directories"); node != nil {
+		v.validateDirectories(node)
+	}
+	if node := lookup(root, "remotes"); node != nil {
+		v.validateRemotes(node)
+	}
+	if node := lookup(root, "includes"); node != nil {
+		v.validateIncludes(node)
+	}
+
+	sort.SliceStable(v.issues, func(i, j int) bool {
+		if v.issues[i].Line != v.issues[j].Line {
+			return v.issues[i].Line < v.issues[j].Line
+		}
+		return v.issues[i].Column < v.issues[j].Column
+	})
+	return v.issues, nil
+}
+
+func (v *validator) add(node *yaml.Node, path, severity, format string, args ...any) {
+	v.issues = append(v.issues, Issue{
+		Line:     node.Line,
+		Column:   node.Column,
+		Path:     path,
+		Severity: severity,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+func (v *validator) expectKind(node *yaml.Node, kind yaml.Kind, path string) bool {
+	if node.Kind == kind {
+		return true
+	}
+	expected := map[yaml.Kind]string{
+		yaml.MappingNode:  "a mapping",
+		yaml.SequenceNode: "a list",
+		yaml.ScalarNode:   "a value",
+	}[kind]
+	v.add(node, path, SeverityError, "expected %s", expected)
+	return false
+}
+
+func (v *validator) checkKeys(mapping *yaml.Node, path string, known []string) {
+	seen := make(map[string]bool)
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		key := mapping.Content[i]
+		if seen[key.Value] {
+			v.add(key, join(path, key.Value), SeverityError, "duplicate key")
+		}
+		seen[key.Value] = true
+
+		if !slices.Contains(known, key.Value) {
+			v.add(key, join(path, key.Value), SeverityError, "unknown key (expected one of %v)", known)
+		}
+	}
+}
+
+func (v *validator) validateVersion(node *yaml.Node) {
+	version, err := strconv.Atoi(node.Value)
+	switch {
+	case node.Kind != yaml.ScalarNode || err != nil || version < 0:
+		v.add(node, "version", SeverityError, "must be a non-negative integer")
+	case version > CurrentVersion:
+		v.add(node, "version", SeverityError, "version %d is newer than this github-switch supports (%d)", version, CurrentVersion)
+	}
+}
+
+func (v *validator) validateAccounts(node *yaml.Node) {
+	if !v.expectKind(node, yaml.MappingNode, "accounts") {
+		return
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		v.accounts[node.Content[i].Value] = true
+	}
+
+	emails := make(map[string]string)
+	keys := make(map[string]string)
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		name := node.Content[i].Value
+		acc := node.Content[i+1]
+		path := join("accounts", name)
+
+		if !v.expectKind(acc, yaml.MappingNode, path) {
+			continue
+		}
+		v.checkKeys(acc, path, accountKeys)
+		v.validateFields(acc, path)
+		v.validateExtends(acc, path, "account "+name, v.cfg.Accounts[name])
+
+		resolved, _ := v.cfg.GetAccount(name)
+		for _, required := range []string{"ssh_key", "name", "email"} {
+			value := lookup(acc, required)
+			switch {
+			case value == nil && fieldValue(resolved, required) != "":
+			case value == nil:
+				v.add(acc, join(path, required), SeverityError, "missing required key")
+			case value.Kind != yaml.ScalarNode || value.Value == "":
+				v.add(value, join(path, required), SeverityError, "must not be empty")
+			}
+		}
+
+		if email := lookup(acc, "email"); email != nil && email.Kind == yaml.ScalarNode && email.Value != "" {
+			if other, ok := emails[email.Value]; ok {
+				v.add(email, join(path, "email"), SeverityError, "email is also used by account '%s'", other)
+			} else {
+				emails[email.Value] = name
+			}
+		}
+
+		if key := lookup(acc, "ssh_key"); key != nil && key.Kind == yaml.ScalarNode && key.Value != "" {
+			if other, ok := keys[key.Value]; ok {
+				v.add(key, join(path, "ssh_key"), SeverityError, "SSH key is also used by account '%s'", other)
+			} else {
+				keys[key.Value] = name
+			}
+		}
+
+		if token := lookup(acc, "token"); token != nil {
+			v.add(token, join(path, "token"), SeverityWarning, "plaintext token; run 'github-switch token get %s' to move it into the encrypted store", name)
+		}
+	}
+}
+
+func (v *validator) validateProfiles(node *yaml.Node) {
+	if !v.expectKind(node, yaml.MappingNode, "profiles") {
+		return
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		name := node.Content[i].Value
+		profile := node.Content[i+1]
+		path := join("profiles", name)
+
+		if !v.expectKind(profile, yaml.MappingNode, path) {
+			continue
+		}
+		v.checkKeys(profile, path, profileKeys)
+		v.validateFields(profile, path)
+		v.validateExtends(profile, path, "profile "+name, v.cfg.Profiles[name])
+	}
+}
+
+// validateFields checks the values of the fields an account or profile
+// sets: a well-formed email, an existing key file and a list of tags.
+func (v *validator) validateFields(node *yaml.Node, path string) {
+	if tags := lookup(node, "tags"); tags != nil && v.expectKind(tags, yaml.SequenceNode, join(path, "tags")) {
+		for i, tag := range tags.Content {
+			tagPath := fmt.Sprintf("%s[%d]", join(path, "tags"), i)
+			if v.expectKind(tag, yaml.ScalarNode, tagPath) && strings.TrimSpace(tag.Value) == "" {
+				v.add(tag, tagPath, SeverityError, "must not be empty")
+			}
+		}
+	}
+
+	if email := lookup(node, "email"); email != nil && email.Kind == yaml.ScalarNode && email.Value != "" {
+		if addr, err := mail.ParseAddress(email.Value); err != nil || addr.Address != email.Value {
+			v.add(email, join(path, "email"), SeverityError, "'%s' is not a valid email address", email.Value)
+		}
+	}
+
+	if key := lookup(node, "ssh_key"); key != nil && key.Kind == yaml.ScalarNode && key.Value != "" && v.opts.SSHDir != "" {
+		keyPath := filepath.Join(v.opts.SSHDir, key.Value)
+		if _, err := os.Stat(keyPath); err != nil {
+			v.add(key, join(path, "ssh_key"), SeverityWarning, "key file %s does not exist", keyPath)
+		}
+	}
+}
+
+// validateExtends reports an extends that names nothing or closes a cycle.
+func (v *validator) validateExtends(node *yaml.Node, path, id string, acc Account) {
+	extends := lookup(node, "extends")
+	if extends == nil || !v.expectKind(extends, yaml.ScalarNode, join(path, "extends")) {
+		return
+	}
+	if _, err := v.cfg.resolve(acc, []string{id}); err != nil {
+		v.add(extends, join(path, "extends"), SeverityError, "%s", err)
+	}
+}
+
+func fieldValue(acc Account, key string) string {
+	switch key {
+	case "ssh_key":
+		return acc.SSHKey
+	case "name":
+		return acc.Name
+	case "email":
+		return acc.Email
+	}
+	return ""
+}
+
+func (v *validator) validateDirectories(node *yaml.Node) {
+	if !v.expectKind(node, yaml.MappingNode, "directories") {
+		return
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		path := join("directories", node.Content[i].Value)
+		v.checkAccountRef(node.Content[i+1], path)
+	}
+}
+
+func (v *validator) validateRemotes(node *yaml.Node) {
+	if !v.expectKind(node, yaml.SequenceNode, "remotes") {
+		return
+	}
+	for i, rule := range node.Content {
+		path := fmt.Sprintf("remotes[%d]", i)
+		if !v.expectKind(rule, yaml.MappingNode, path) {
+			continue
+		}
+		v.checkKeys(rule, path, remoteRuleKeys)
+
+		if pattern := lookup(rule, "pattern"); pattern == nil {
+			v.add(rule, join(path, "pattern"), SeverityError, "missing required key")
+		} else if pattern.Value == "" {
+			v.add(pattern, join(path, "pattern"), SeverityError, "must not be empty")
+		}
+
+		if account := lookup(rule, "account"); account == nil {
+			v.add(rule, join(path, "account"), SeverityError, "missing required key")
+		} else {
+			v.checkAccountRef(account, join(path, "account"))
+		}
+	}
+}
+
+func (v *validator) validateIncludes(node *yaml.Node) {
+	if !v.expectKind(node, yaml.SequenceNode, "includes") {
+		return
+	}
+	for i, include := range node.Content {
+		path := fmt.Sprintf("includes[%d]", i)
+		if !v.expectKind(include, yaml.ScalarNode, path) {
+			continue
+		}
+		if _, err := os.Stat(includePath(include.Value)); err != nil {
+			v.add(include, path, SeverityWarning, "%s does not exist", includePath(include.Value))
+		}
+	}
+}
+
+func (v *validator) checkAccountRef(node *yaml.Node, path string) {
+	if !v.expectKind(node, yaml.ScalarNode, path) {
+		return
+	}
+	if !v.accounts[node.Value] {
+		v.add(node, path, SeverityError, "unknown account '%s'", node.Value)
+	}
+}
+
+func join(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
@@ -0,0 +1,160 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValidateValidConfig(t *testing.T) {
+	sshDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(sshDir, "id_work"), nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	data := `version: 1
+accounts:
+  work:
+    ssh_key: id_work
+    name: Work
+    email: work@example.com
+directories:
+  ~/work: work
+remotes:
+  - pattern: github.com:acme/*
+    account: work
+`
+	issues, err := Validate([]byte(data), ValidateOptions{SSHDir: sshDir})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(issues) != 0 {
+		t.Errorf("expected no issues, got %v", issues)
+	}
+}
+
+func TestValidateReportsPositions(t *testing.T) {
+	data := `accounts:
+  work:
+    ssh_key: id_work
+    name: Work
+    email: not-an-email
+    colour: blue
+  personal:
+    ssh_key: id_work
+    email: work@example.com
+directories:
+  ~/src: missing
+`
+	issues, err := Validate([]byte(data), ValidateOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []struct {
+		line, column int
+		path, text   string
+	}{
+		{5, 12, "accounts.work.email", "not a valid email"},
+		{6, 5, "accounts.work.colour", "unknown key"},
+		{8, 5, "accounts.personal.name", "missing required key"},
+		{8, 14, "accounts.personal.ssh_key", "also used by account 'work'"},
+		{11, 10, "directories.~/src", "unknown account 'missing'"},
+	}
+	if len(issues) != len(expected) {
+		t.Fatalf("expected %d issues, got %d: %v", len(expected), len(issues), issues)
+	}
+	for i, want := range expected {
+		got := issues[i]
+		if got.Line != want.line || got.Column != want.column || got.Path != want.path || !strings.Contains(got.Message, want.text) {
+			t.Errorf("issue %d: expected %d:%d %s %q, got %v", i, want.line, want.column, want.path, want.text, got)
+		}
+	}
+}
+
+func TestValidateMissingKeyFileIsWarning(t *testing.T) {
+	data := "accounts:\n  work:\n    ssh_key: id_missing\n    name: Work\n    email: work@example.com\n"
+
+	issues, err := Validate([]byte(data), ValidateOptions{SSHDir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(issues) != 1 || issues[0].Severity != SeverityWarning {
+		t.Errorf("expected a single warning, got %v", issues)
+	}
+}
+
+func TestValidateRemoteRules(t *testing.T) {
+	data := `accounts: {}
+remotes:
+  - pattern: github.com:acme/*
+  - account: nobody
+    extra: true
+`
+	issues, err := Validate([]byte(data), ValidateOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	paths := make([]string, len(issues))
+	for i, issue := range issues {
+		paths[i] = issue.Path
+	}
+	want := "remotes[0].account remotes[1].pattern remotes[1].account remotes[1].extra"
+	if got := strings.Join(paths, " "); got != want {
+		t.Errorf("expected issues at %q, got %q", want, got)
+	}
+}
+
+func TestValidateSyntaxError(t *testing.T) {
+	if _, err := Validate([]byte("accounts: [\n"), ValidateOptions{}); err == nil {
+		t.Error("expected a syntax error")
+	}
+}
+
+func TestValidateProfiles(t *testing.T) {
+	data := `profiles:
+  work-base:
+    name: Work Me
+    email: me@work.example
+    token: nope
+accounts:
+  client-a:
+    extends: work-base
+    ssh_key: id_a
+  client-b:
+    extends: client-c
+    ssh_key: id_b
+  client-c:
+    extends: client-b
+`
+	issues, err := Validate([]byte(data), ValidateOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var got []string
+	for _, issue := range issues {
+		got = append(got, fmt.Sprintf("%d:%s", issue.Line, issue.Path))
+	}
+	// client-a inherits name and email; the cycle is reported on both ends
+	// and the accounts in it lack the fields nothing provides.
+	expected := "5:profiles.work-base.token 11:accounts.client-b.name 11:accounts.client-b.email 11:accounts.client-b.extends 14:accounts.client-c.ssh_key 14:accounts.client-c.name 14:accounts.client-c.email 14:accounts.client-c.extends"
+	if strings.Join(got, " ") != expected {
+		t.Errorf("expected issues %q, got %q", expected, strings.Join(got, " "))
+	}
+}
+
+func TestValidateTags(t *testing.T) {
+	data := "accounts:\n  work:\n    ssh_key: id_work\n    name: Work\n    email: work@example.com\n    tags: client\n  oss:\n    ssh_key: id_oss\n    name: OSS\n    email: oss@example.com\n    tags: [oss, \"\"]\n"
+
+	issues, err := Validate([]byte(data), ValidateOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(issues) != 2 || issues[0].Path != "accounts.work.tags" || issues[1].Path != "accounts.oss.tags[1]" {
+		t.Errorf("unexpected issues: %v", issues)
+	}
+}
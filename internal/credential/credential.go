@@ -0,0 +1,77 @@
+// Package credential implements the Git credential helper protocol.
+package credential
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Request holds the attributes Git sends to a credential helper. Unknown
+// attributes are ignored.
+type Request struct {
+	Protocol string
+	Host     string
+	Path     string
+	Username string
+	Password string
+}
+
+// URL reassembles the request into a URL, including the path when Git sent
+// one (credential.useHttpPath).
+func (r *Request) URL() string {
+	url := r.Protocol + "://" + r.Host
+	if r.Path != "" {
+		url += "/" + strings.TrimPrefix(r.Path, "/")
+	}
+	return url
+}
+
+// Read parses key=value lines until a blank line or end of input.
+func Read(r io.Reader) (*Request, error) {
+	req := &Request{}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			break
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid credential attribute: %s", line)
+		}
+
+		switch key {
+		case "protocol":
+			req.Protocol = value
+		case "host":
+			req.Host = value
+		case "path":
+			req.Path = value
+		case "username":
+			req.Username = value
+		case "password":
+			req.Password = value
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read credential request: %w", err)
+	}
+
+	return req, nil
+}
+
+// Write sends a username and password back to Git.
+func Write(w io.Writer, username, password string) error {
+	for _, v := range []string{username, password} {
+		if strings.ContainsAny(v, "\n\x00") {
+			return fmt.Errorf("credential values must not contain newlines or NUL bytes")
+		}
+	}
+
+	_, err := fmt.Fprintf(w, "username=%s\npassword=%s\n", username, password)
+	return err
+}
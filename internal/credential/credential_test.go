@@ -0,0 +1,43 @@
+package credential
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestRead(t *testing.T) {
+	input := "capability[]=authtype\nprotocol=https\nhost=github.com\npath=acme-corp/app.git\nusername=jane\n\nignored=after-blank\n"
+
+	req, err := Read(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if req.Protocol != "https" || req.Host != "github.com" || req.Path != "acme-corp/app.git" || req.Username != "jane" {
+		t.Errorf("unexpected request: %+v", req)
+	}
+	if got := req.URL(); got != "https://github.com/acme-corp/app.git" {
+		t.Errorf("expected URL https://github.com/acme-corp/app.git, got %s", got)
+	}
+}
+
+func TestReadInvalidLine(t *testing.T) {
+	if _, err := Read(strings.NewReader("protocol https\n")); err == nil {
+		t.Error("expected an error for a line without '='")
+	}
+}
+
+func TestWrite(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, "jane", "ghp_secret"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if buf.String() != "username=jane\npassword=ghp_secret\n" {
+		t.Errorf("unexpected output: %q", buf.String())
+	}
+
+	if err := Write(&buf, "jane", "bad\nvalue"); err == nil {
+		t.Error("expected an error for a value containing a newline")
+	}
+}
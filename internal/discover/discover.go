@@ -0,0 +1,229 @@
+// Package discover proposes accounts from an existing hand-rolled setup:
+// GitHub host aliases in the SSH config and the identities set by
+// conditionally included Git config files.
+package discover
+
+import (
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/naxodev/github-switch/internal/config"
+	"github.com/naxodev/github-switch/internal/ssh"
+)
+
+// Identity is a Git identity and the include condition that selects it.
+// The identity of the global config has an empty Condition.
+type Identity struct {
+	Condition string
+	Path      string
+	Name      string
+	Email     string
+}
+
+// Candidate is a proposed account. Directory is set when the identity was
+// included for a gitdir: condition and becomes a directory rule.
+type Candidate struct {
+	Label     string
+	Account   config.Account
+	Directory string
+	Sources   []string
+}
+
+// Complete reports whether the candidate has every required field.
+func (c Candidate) Complete() bool {
+	return c.Account.Name != "" && c.Account.Email != "" && c.Account.SSHKey != ""
+}
+
+// Candidates pairs the GitHub hosts of the SSH config with the identities
+// whose include path or condition mentions the same label, e.g. host
+// github.com-work with [includeIf "gitdir:~/work/"]. The plain github.com
+// host falls back to the global identity. Identities left over become
+// candidates without a key. Keys and emails already used by an account in
+// cfg are skipped.
+func Candidates(hosts []ssh.Host, identities []Identity, global Identity, cfg *config.Config) []Candidate {
+	usedKeys := make(map[string]bool)
+	usedEmails := make(map[string]bool)
+	for _, name := range cfg.ListAccounts() {
+		acc, _ := cfg.GetAccount(name)
+		usedKeys[acc.SSHKey] = true
+		usedEmails[strings.ToLower(acc.Email)] = true
+	}
+
+	matched := make([]bool, len(identities))
+	var candidates []Candidate
+
+	for _, host := range hosts {
+		alias := concreteAlias(host)
+		if !isGitHubHost(alias, host.HostName) {
+			continue
+		}
+
+		for _, identityFile := range host.IdentityFiles {
+			key, ok := ssh.KeyName(identityFile)
+			if !ok || usedKeys[key] {
+				continue
+			}
+			usedKeys[key] = true
+
+			c := Candidate{
+				Label:   label(alias, key),
+				Account: config.Account{SSHKey: key, Host: enterpriseHost(alias, host.HostName)},
+				Sources: []string{"SSH host " + alias},
+			}
+
+			identity := -1
+			for i, id := range identities {
+				if !matched[i] && mentions(id, c.Label) {
+					identity = i
+					break
+				}
+			}
+			switch {
+			case identity >= 0:
+				matched[identity] = true
+				c.apply(identities[identity])
+			case strings.EqualFold(alias, config.DefaultHost) && global.Email != "":
+				c.apply(global)
+			}
+
+			candidates = append(candidates, c)
+		}
+	}
+
+	for i, id := range identities {
+		if matched[i] || id.Email == "" {
+			continue
+		}
+		c := Candidate{Label: includeLabel(id)}
+		c.apply(id)
+		candidates = append(candidates, c)
+	}
+
+	var result []Candidate
+	taken := make(map[string]bool)
+	for name := range cfg.Accounts {
+		taken[name] = true
+	}
+	for _, c := range candidates {
+		if c.Account.Email != "" && usedEmails[strings.ToLower(c.Account.Email)] {
+			continue
+		}
+		c.Label = unique(c.Label, taken)
+		taken[c.Label] = true
+		result = append(result, c)
+	}
+	return result
+}
+
+func (c *Candidate) apply(id Identity) {
+	c.Account.Name = id.Name
+	c.Account.Email = id.Email
+	if id.Condition == "" {
+		c.Sources = append(c.Sources, "global Git config")
+		return
+	}
+	c.Sources = append(c.Sources, id.Path)
+	c.Directory = gitdir(id.Condition)
+}
+
+// concreteAlias returns the first Host pattern without wildcards or negation.
+func concreteAlias(host ssh.Host) string {
+	for _, pattern := range host.Patterns {
+		if !strings.ContainsAny(pattern, "*?!") {
+			return pattern
+		}
+	}
+	return ""
+}
+
+func isGitHubHost(alias, hostName string) bool {
+	if alias == "" {
+		return false
+	}
+	return strings.Contains(strings.ToLower(alias), "github") ||
+		strings.Contains(strings.ToLower(hostName), "github")
+}
+
+// enterpriseHost returns the GitHub host for accounts not on github.com.
+func enterpriseHost(alias, hostName string) string {
+	host := strings.ToLower(hostName)
+	if host == "" {
+		host = strings.ToLower(alias)
+	}
+	// github.com-<label> is the usual alias form, but hosts such as
+	// github.company.com merely start with the same text.
+	if host == config.DefaultHost || host == "ssh."+config.DefaultHost || strings.HasPrefix(host, config.DefaultHost+"-") {
+		return ""
+	}
+	return host
+}
+
+func mentions(id Identity, label string) bool {
+	text := strings.ToLower(id.Condition + " " + filepath.Base(id.Path))
+	return strings.Contains(text, strings.ToLower(label))
+}
+
+// label derives an account name from a host alias such as github.com-work
+// or work.github, or failing that from a key name such as id_work_ed25519.
+func label(alias, key string) string {
+	name := strings.ToLower(alias)
+	name = strings.ReplaceAll(name, config.DefaultHost, "")
+	name = strings.ReplaceAll(name, "github", "")
+	if name = strings.Trim(name, "-_."); name != "" {
+		return name
+	}
+
+	name = strings.TrimPrefix(filepath.Base(key), "id_")
+	for _, algorithm := range []string{"rsa", "ed25519", "ecdsa", "dsa"} {
+		name = strings.TrimSuffix(name, "_"+algorithm)
+		name = strings.TrimSuffix(name, "-"+algorithm)
+		if name == algorithm {
+			name = ""
+		}
+	}
+	if name == "" {
+		return "default"
+	}
+	return name
+}
+
+// includeLabel derives an account name from an include path such as
+// ~/.gitconfig-work, or from the last directory of a gitdir: condition.
+func includeLabel(id Identity) string {
+	name := strings.TrimPrefix(filepath.Base(id.Path), ".")
+	name = strings.TrimPrefix(name, "gitconfig")
+	if name = strings.Trim(name, "-_."); name != "" {
+		return name
+	}
+	if dir := gitdir(id.Condition); dir != "" {
+		return filepath.Base(dir)
+	}
+	return "imported"
+}
+
+// gitdir returns the directory of a gitdir: include condition in the form
+// directory rules use, or an empty string for other conditions.
+func gitdir(condition string) string {
+	dir, ok := strings.CutPrefix(condition, "gitdir:")
+	if !ok {
+		dir, ok = strings.CutPrefix(condition, "gitdir/i:")
+	}
+	if !ok || !(strings.HasPrefix(dir, "~/") || filepath.IsAbs(dir)) {
+		return ""
+	}
+	dir = strings.TrimSuffix(dir, "**")
+	return strings.TrimSuffix(dir, "/")
+}
+
+func unique(name string, taken map[string]bool) string {
+	if !taken[name] {
+		return name
+	}
+	for i := 2; ; i++ {
+		candidate := name + "-" + strconv.Itoa(i)
+		if !taken[candidate] {
+			return candidate
+		}
+	}
+}
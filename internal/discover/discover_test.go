@@ -0,0 +1,104 @@
+package discover
+
+import (
+	"testing"
+
+	"github.com/naxodev/github-switch/internal/config"
+	"github.com/naxodev/github-switch/internal/env"
+	"github.com/naxodev/github-switch/internal/ssh"
+)
+
+func TestCandidates(t *testing.T) {
+	ssh.SetEnv(&env.Env{Home: "/home/me", SSHDir: "/home/me/.ssh"})
+	defer ssh.SetEnv(env.Default())
+
+	hosts := []ssh.Host{
+		{Patterns: []string{"github.com"}, IdentityFiles: []string{"~/.ssh/id_ed25519"}},
+		{Patterns: []string{"github.com-work"}, HostName: "github.com", IdentityFiles: []string{"~/.ssh/id_work"}},
+		{Patterns: []string{"ghe"}, HostName: "github.acme.com", IdentityFiles: []string{"~/.ssh/id_acme"}},
+		{Patterns: []string{"gitlab.com"}, IdentityFiles: []string{"~/.ssh/id_gitlab"}},
+		{Patterns: []string{"*"}, IdentityFiles: []string{"~/.ssh/id_default"}},
+	}
+	identities := []Identity{
+		{Condition: "gitdir:~/oss/", Path: "/home/me/.gitconfig-oss", Name: "Me", Email: "me@oss.example"},
+		{Condition: "gitdir:~/work/", Path: "/home/me/.gitconfig-work", Name: "Work Me", Email: "me@work.example"},
+	}
+	global := Identity{Name: "Me", Email: "me@home.example"}
+	cfg := &config.Config{Accounts: map[string]config.Account{
+		"oss": {SSHKey: "id_other", Email: "someone@else.example"},
+	}}
+
+	candidates := Candidates(hosts, identities, global, cfg)
+
+	expected := []struct {
+		label, key, email, host, directory string
+	}{
+		{"default", "id_ed25519", "me@home.example", "", ""},
+		{"work", "id_work", "me@work.example", "", "~/work"},
+		{"ghe", "id_acme", "", "github.acme.com", ""},
+		{"oss-2", "", "me@oss.example", "", "~/oss"},
+	}
+	if len(candidates) != len(expected) {
+		t.Fatalf("expected %d candidates, got %d: %+v", len(expected), len(candidates), candidates)
+	}
+	for i, want := range expected {
+		got := candidates[i]
+		if got.Label != want.label || got.Account.SSHKey != want.key || got.Account.Email != want.email ||
+			got.Account.Host != want.host || got.Directory != want.directory {
+			t.Errorf("candidate %d: expected %+v, got %+v", i, want, got)
+		}
+	}
+
+	if !candidates[1].Complete() || candidates[2].Complete() {
+		t.Error("expected only candidates with name, email and key to be complete")
+	}
+}
+
+func TestCandidatesSkipConfiguredAccounts(t *testing.T) {
+	ssh.SetEnv(&env.Env{Home: "/home/me", SSHDir: "/home/me/.ssh"})
+	defer ssh.SetEnv(env.Default())
+
+	hosts := []ssh.Host{{Patterns: []string{"github.com"}, IdentityFiles: []string{"~/.ssh/id_work"}}}
+	identities := []Identity{{Condition: "gitdir:~/x/", Path: "/home/me/.gitconfig-x", Email: "me@work.example"}}
+	cfg := &config.Config{Accounts: map[string]config.Account{
+		"work": {SSHKey: "id_work", Email: "me@work.example"},
+	}}
+
+	if candidates := Candidates(hosts, identities, Identity{}, cfg); len(candidates) != 0 {
+		t.Errorf("expected no candidates, got %+v", candidates)
+	}
+}
+
+func TestLabel(t *testing.T) {
+	tests := []struct {
+		alias, key, expected string
+	}{
+		{"github.com-work", "id_x", "work"},
+		{"work.github", "id_x", "work"},
+		{"github.com", "id_personal_rsa", "personal"},
+		{"github.com", "id_ed25519", "default"},
+	}
+	for _, tt := range tests {
+		if got := label(tt.alias, tt.key); got != tt.expected {
+			t.Errorf("label(%q, %q) = %q, expected %q", tt.alias, tt.key, got, tt.expected)
+		}
+	}
+}
+
+func TestEnterpriseHost(t *testing.T) {
+	tests := []struct {
+		alias, hostName, expected string
+	}{
+		{"github.com-work", "github.com", ""},
+		{"github.com-work", "", ""},
+		{"gh-ssh", "ssh.github.com", ""},
+		{"corp", "github.company.com", "github.company.com"},
+		{"github.company.com", "", "github.company.com"},
+		{"github.comcast.net", "", "github.comcast.net"},
+	}
+	for _, tt := range tests {
+		if got := enterpriseHost(tt.alias, tt.hostName); got != tt.expected {
+			t.Errorf("enterpriseHost(%q, %q) = %q, expected %q", tt.alias, tt.hostName, got, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+// Package env describes the files and executables github-switch operates on,
+// so they can be pointed somewhere other than the current user's home.
+package env
+
+import (
+	"os"
+	"path/filepath"
+)
+
+type Env struct {
+	// Home is the home directory of the user being managed.
+	Home string
+	// SSHDir holds the SSH keys accounts refer to by file name.
+	SSHDir string
+	// SSHConfig is the SSH client config whose github.com block is updated.
+	SSHConfig string
+	// GitConfig replaces the global Git config file. Empty means Git's own
+	// default (~/.gitconfig or $XDG_CONFIG_HOME/git/config).
+	GitConfig string
+
+	Git       string
+	SSHAdd    string
+	SSHKeygen string
+	GH        string
+}
+
+// Default returns the environment of the current user.
+func Default() *Env {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		home = ""
+	}
+
+	return &Env{
+		Home:      home,
+		SSHDir:    filepath.Join(home, ".ssh"),
+		SSHConfig: filepath.Join(home, ".ssh", "config"),
+		Git:       "git",
+		SSHAdd:    "ssh-add",
+		SSHKeygen: "ssh-keygen",
+		GH:        "gh",
+	}
+}
+
+// WithSSHConfig points the environment at another SSH config. Keys are
+// expected next to it.
+func (e *Env) WithSSHConfig(path string) *Env {
+	e.SSHConfig = path
+	e.SSHDir = filepath.Dir(path)
+	return e
+}
@@ -0,0 +1,189 @@
+// Package gh switches the active account of the GitHub CLI.
+package gh
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/naxodev/github-switch/internal/env"
+	"github.com/naxodev/github-switch/internal/xdg"
+	"gopkg.in/yaml.v3"
+)
+
+var environ = env.Default()
+
+// SetEnv points the package at the gh executable of e.
+func SetEnv(e *env.Env) {
+	environ = e
+}
+
+// ConfigDir returns the GitHub CLI config directory, honouring GH_CONFIG_DIR
+// and XDG_CONFIG_HOME like gh itself.
+func ConfigDir() (string, error) {
+	if dir := os.Getenv("GH_CONFIG_DIR"); dir != "" {
+		return dir, nil
+	}
+	dir, err := xdg.ConfigHome()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "gh"), nil
+}
+
+// LoggedIn reports whether gh has any login for host.
+func LoggedIn(host string) (bool, error) {
+	dir, err := ConfigDir()
+	if err != nil {
+		return false, err
+	}
+
+	root, err := loadHosts(dir)
+	if err != nil || root == nil {
+		return false, err
+	}
+	return mappingValue(root, host) != nil, nil
+}
+
+// ActiveUser returns the user gh acts as on host, or an empty string when gh
+// is not logged in there.
+func ActiveUser(host string) (string, error) {
+	dir, err := ConfigDir()
+	if err != nil {
+		return "", err
+	}
+
+	root, err := loadHosts(dir)
+	if err != nil || root == nil {
+		return "", err
+	}
+	hostNode := mappingValue(root, host)
+	if hostNode == nil {
+		return "", nil
+	}
+	if userNode := mappingValue(hostNode, "user"); userNode != nil {
+		return userNode.Value, nil
+	}
+	return "", nil
+}
+
+// Switch makes user the active gh account on host. It uses 'gh auth switch'
+// when gh is installed, so tokens held in the system keyring follow, and
+// edits hosts.yml directly otherwise.
+func Switch(host, user string) error {
+	if path, err := exec.LookPath(environ.GH); err == nil {
+		cmd := exec.Command(path, "auth", "switch", "--hostname", host, "--user", user)
+		if output, err := cmd.CombinedOutput(); err != nil {
+			return fmt.Errorf("gh auth switch failed: %w: %s", err, output)
+		}
+		return nil
+	}
+
+	dir, err := ConfigDir()
+	if err != nil {
+		return err
+	}
+	return SwitchHostsFile(dir, host, user)
+}
+
+// SwitchHostsFile marks user as the active account for host in the
+// hosts.yml inside dir. The user must already be logged in with gh.
+func SwitchHostsFile(dir, host, user string) error {
+	root, err := loadHosts(dir)
+	if err != nil {
+		return err
+	}
+	if root == nil {
+		return fmt.Errorf("gh is not logged in to %s", host)
+	}
+
+	hostNode := mappingValue(root, host)
+	if hostNode == nil || hostNode.Kind != yaml.MappingNode {
+		return fmt.Errorf("gh is not logged in to %s", host)
+	}
+
+	users := mappingValue(hostNode, "users")
+	userNode := (*yaml.Node)(nil)
+	if users != nil {
+		userNode = mappingValue(users, user)
+	}
+	if userNode == nil {
+		return fmt.Errorf("gh is not logged in as %s on %s (run 'gh auth login --hostname %s')", user, host, host)
+	}
+
+	setMappingValue(hostNode, "user", user)
+
+	// With insecure storage the active token is duplicated at host level.
+	if token := mappingValue(userNode, "oauth_token"); token != nil {
+		setMappingValue(hostNode, "oauth_token", token.Value)
+	} else {
+		deleteMappingValue(hostNode, "oauth_token")
+	}
+
+	data, err := yaml.Marshal(&yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{root}})
+	if err != nil {
+		return fmt.Errorf("failed to marshal gh hosts: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "hosts.yml"), data, 0o600); err != nil {
+		return fmt.Errorf("failed to write gh hosts: %w", err)
+	}
+
+	return nil
+}
+
+// loadHosts parses hosts.yml in dir. It returns nil when the file is missing.
+func loadHosts(dir string) (*yaml.Node, error) {
+	data, err := os.ReadFile(filepath.Join(dir, "hosts.yml"))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read gh hosts: %w", err)
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse gh hosts: %w", err)
+	}
+	if len(doc.Content) == 0 {
+		return nil, nil
+	}
+	return doc.Content[0], nil
+}
+
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+func setMappingValue(node *yaml.Node, key, value string) {
+	if existing := mappingValue(node, key); existing != nil {
+		existing.Kind = yaml.ScalarNode
+		existing.Tag = "!!str"
+		existing.Value = value
+		existing.Content = nil
+		return
+	}
+	node.Content = append(node.Content,
+		&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key},
+		&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value},
+	)
+}
+
+func deleteMappingValue(node *yaml.Node, key string) {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			node.Content = append(node.Content[:i], node.Content[i+2:]...)
+			return
+		}
+	}
+}
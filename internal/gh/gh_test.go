@@ -0,0 +1,85 @@
+package gh
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const hostsFixture = `github.com:
+    users:
+        jane-personal:
+            oauth_token: gho_personal
+        jane-work:
+            oauth_token: gho_work
+    git_protocol: ssh
+    oauth_token: gho_personal
+    user: jane-personal
+github.mycorp.com:
+    users:
+        jane-corp:
+    git_protocol: https
+    user: jane-corp
+`
+
+func writeHosts(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "hosts.yml"), []byte(hostsFixture), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("GH_CONFIG_DIR", dir)
+	return dir
+}
+
+func TestSwitchHostsFile(t *testing.T) {
+	dir := writeHosts(t)
+
+	if err := SwitchHostsFile(dir, "github.com", "jane-work"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	user, err := ActiveUser("github.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user != "jane-work" {
+		t.Errorf("expected active user 'jane-work', got '%s'", user)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "hosts.yml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	content := string(data)
+	if !strings.Contains(content, "oauth_token: gho_work\n    user: jane-work") {
+		t.Errorf("expected host-level token to follow the active user:\n%s", content)
+	}
+	if !strings.Contains(content, "github.mycorp.com:\n    users:\n        jane-corp:") {
+		t.Errorf("expected other hosts to be preserved:\n%s", content)
+	}
+}
+
+func TestSwitchHostsFileUnknownUser(t *testing.T) {
+	dir := writeHosts(t)
+
+	if err := SwitchHostsFile(dir, "github.com", "someone-else"); err == nil {
+		t.Error("expected an error for a user gh is not logged in as")
+	}
+	if err := SwitchHostsFile(dir, "gitlab.com", "jane-work"); err == nil {
+		t.Error("expected an error for a host gh is not logged in to")
+	}
+}
+
+func TestActiveUserWithoutHosts(t *testing.T) {
+	t.Setenv("GH_CONFIG_DIR", t.TempDir())
+
+	user, err := ActiveUser("github.com")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if user != "" {
+		t.Errorf("expected no active user, got '%s'", user)
+	}
+}
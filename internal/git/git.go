@@ -3,10 +3,32 @@ package git
 
 import (
 	"fmt"
+	"os"
 	"os/exec"
+	"path/filepath"
+	"sort"
 	"strings"
+
+	"github.com/naxodev/github-switch/internal/env"
 )
 
+var environ = env.Default()
+
+// SetEnv points the package at the Git executable and global config of e.
+func SetEnv(e *env.Env) {
+	environ = e
+}
+
+// command prepares a git invocation. When the environment names a global
+// config file, git is told to use it in place of its own.
+func command(args ...string) *exec.Cmd {
+	cmd := exec.Command(environ.Git, args...)
+	if environ.GitConfig != "" {
+		cmd.Env = append(os.Environ(), "GIT_CONFIG_GLOBAL="+environ.GitConfig)
+	}
+	return cmd
+}
+
 func UpdateGlobalConfig(name, email string) error {
 	configs := map[string]string{
 		"user.name":  name,
@@ -14,7 +36,7 @@ func UpdateGlobalConfig(name, email string) error {
 	}
 
 	for key, value := range configs {
-		cmd := exec.Command("git", "config", "--global", key, value)
+		cmd := command("config", "--global", key, value)
 		if err := cmd.Run(); err != nil {
 			return fmt.Errorf("failed to set %s: %w", key, err)
 		}
@@ -24,7 +46,225 @@ func UpdateGlobalConfig(name, email string) error {
 }
 
 func GetGlobalConfig(key string) (string, error) {
-	cmd := exec.Command("git", "config", "--global", "--get", key)
+	return getConfig(key, "config", "--global", "--get", key)
+}
+
+// SetGlobalConfig writes a single key to the global Git config.
+func SetGlobalConfig(key, value string) error {
+	cmd := command("config", "--global", key, value)
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to set %s: %w", key, err)
+	}
+	return nil
+}
+
+// UnsetGlobalConfig removes a key from the global Git config. It is not an
+// error if the key is not set.
+func UnsetGlobalConfig(key string) error {
+	cmd := command("config", "--global", "--unset", key)
+	if err := cmd.Run(); err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 5 {
+			return nil
+		}
+		return fmt.Errorf("failed to unset %s: %w", key, err)
+	}
+	return nil
+}
+
+// GetConfig reads the effective value of key for repo, across all scopes.
+func GetConfig(repo, key string) (string, error) {
+	return getConfig(key, "-C", repo, "config", "--get", key)
+}
+
+// GetLocalConfig reads a key from the repository-local config of repo.
+func GetLocalConfig(repo, key string) (string, error) {
+	return getConfig(key, "-C", repo, "config", "--local", "--get", key)
+}
+
+// UpdateLocalConfig writes values into the repository-local config of repo.
+func UpdateLocalConfig(repo string, values map[string]string) error {
+	for key, value := range values {
+		cmd := command("-C", repo, "config", "--local", key, value)
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("failed to set %s: %w", key, err)
+		}
+	}
+
+	return nil
+}
+
+// RepoRoot returns the top-level directory of the repository containing dir,
+// or an empty string when dir is not inside a repository.
+func RepoRoot(dir string) (string, error) {
+	cmd := command("-C", dir, "rev-parse", "--show-toplevel")
+	output, err := cmd.Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 128 {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to find repository root: %w", err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// HooksDir returns the repository's own hooks directory, ignoring any
+// core.hooksPath override.
+func HooksDir(repo string) (string, error) {
+	cmd := command("-C", repo, "rev-parse", "--git-common-dir")
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to find Git directory: %w", err)
+	}
+
+	gitDir := strings.TrimSpace(string(output))
+	if !filepath.IsAbs(gitDir) {
+		gitDir = filepath.Join(repo, gitDir)
+	}
+	return filepath.Join(gitDir, "hooks"), nil
+}
+
+// AuthorEmail returns the email Git would record as the author of a commit
+// made in repo, taking environment overrides into account.
+func AuthorEmail(repo string) (string, error) {
+	cmd := command("-C", repo, "var", "GIT_AUTHOR_IDENT")
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to determine commit author: %w", err)
+	}
+
+	ident := string(output)
+	start := strings.Index(ident, "<")
+	end := strings.LastIndex(ident, ">")
+	if start < 0 || end < start {
+		return "", fmt.Errorf("unexpected author identity: %s", strings.TrimSpace(ident))
+	}
+	return ident[start+1 : end], nil
+}
+
+type Remote struct {
+	Name string
+	URL  string
+}
+
+// Remotes lists the remotes configured for repo, with origin first and the
+// rest in name order.
+func Remotes(repo string) ([]Remote, error) {
+	cmd := command("-C", repo, "config", "--get-regexp", `^remote\..*\.url$`)
+	output, err := cmd.Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 1 {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to list remotes: %w", err)
+	}
+
+	var remotes []Remote
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		key, url, ok := strings.Cut(line, " ")
+		if !ok {
+			continue
+		}
+		name := strings.TrimSuffix(strings.TrimPrefix(key, "remote."), ".url")
+		remotes = append(remotes, Remote{Name: name, URL: url})
+	}
+
+	sort.SliceStable(remotes, func(i, j int) bool {
+		if (remotes[i].Name == "origin") != (remotes[j].Name == "origin") {
+			return remotes[i].Name == "origin"
+		}
+		return remotes[i].Name < remotes[j].Name
+	})
+	return remotes, nil
+}
+
+// NormalizeRemote reduces SSH, scp-style and HTTPS remote URLs to the
+// canonical form host:owner/repo, dropping users, ports and a .git suffix.
+// For example git@github.com:acme/app.git and https://github.com/acme/app
+// both become github.com:acme/app.
+func NormalizeRemote(url string) string {
+	var host, path string
+
+	if scheme, rest, ok := strings.Cut(url, "://"); ok && scheme != "" {
+		host, path, _ = strings.Cut(rest, "/")
+		if i := strings.LastIndex(host, "@"); i >= 0 {
+			host = host[i+1:]
+		}
+		if h, _, ok := strings.Cut(host, ":"); ok {
+			host = h
+		}
+	} else if h, p, ok := strings.Cut(url, ":"); ok {
+		host, path = h, p
+		if i := strings.LastIndex(host, "@"); i >= 0 {
+			host = host[i+1:]
+		}
+	} else {
+		return url
+	}
+
+	path = strings.TrimSuffix(strings.Trim(path, "/"), ".git")
+	return host + ":" + path
+}
+
+// Include is a conditional include of the global Git config, such as
+// [includeIf "gitdir:~/work/"] with path = ~/.gitconfig-work.
+type Include struct {
+	Condition string
+	Path      string
+}
+
+// Includes lists the conditional includes of the global Git config. Relative
+// paths are resolved against the directory of the global config.
+func Includes() ([]Include, error) {
+	cmd := command("config", "--global", "--null", "--type=path", "--get-regexp", `^includeif\..*\.path$`)
+	output, err := cmd.Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 1 {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to list includes: %w", err)
+	}
+
+	base := environ.Home
+	if environ.GitConfig != "" {
+		base = filepath.Dir(environ.GitConfig)
+	}
+
+	includes := parseIncludes(string(output))
+	for i := range includes {
+		if !filepath.IsAbs(includes[i].Path) {
+			includes[i].Path = filepath.Join(base, includes[i].Path)
+		}
+	}
+	return includes, nil
+}
+
+// parseIncludes parses the NUL-separated "key\nvalue" pairs printed by
+// git config --null --get-regexp.
+func parseIncludes(output string) []Include {
+	var includes []Include
+	for _, entry := range strings.Split(output, "\x00") {
+		key, value, ok := strings.Cut(entry, "\n")
+		if !ok || value == "" {
+			continue
+		}
+		// Git lower-cases the section name but the condition keeps its case.
+		condition, ok := strings.CutPrefix(key, "includeif.")
+		if !ok {
+			continue
+		}
+		condition = strings.TrimSuffix(condition, ".path")
+		includes = append(includes, Include{Condition: condition, Path: value})
+	}
+	return includes
+}
+
+// GetFileConfig reads a value from a specific Git config file.
+func GetFileConfig(path, key string) (string, error) {
+	return getConfig(key, "config", "--file", path, key)
+}
+
+func getConfig(key string, args ...string) (string, error) {
+	cmd := command(args...)
 	output, err := cmd.Output()
 	if err != nil {
 		if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 1 {
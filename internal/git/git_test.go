@@ -0,0 +1,37 @@
+package git
+
+import "testing"
+
+func TestNormalizeRemote(t *testing.T) {
+	tests := map[string]string{
+		"git@github.com:acme-corp/app.git":          "github.com:acme-corp/app",
+		"github.com:acme-corp/app":                  "github.com:acme-corp/app",
+		"https://github.com/acme-corp/app.git":      "github.com:acme-corp/app",
+		"https://user@github.com/acme-corp/app/":    "github.com:acme-corp/app",
+		"ssh://git@github.mycorp.com:2222/team/app": "github.mycorp.com:team/app",
+		"git@github.com-work:acme-corp/app.git":     "github.com-work:acme-corp/app",
+		"/srv/git/app.git":                          "/srv/git/app.git",
+	}
+
+	for url, expected := range tests {
+		if got := NormalizeRemote(url); got != expected {
+			t.Errorf("NormalizeRemote(%q): expected '%s', got '%s'", url, expected, got)
+		}
+	}
+}
+
+func TestParseIncludes(t *testing.T) {
+	output := "includeif.gitdir:~/Work/.path\n/home/me/.gitconfig-work\x00" +
+		"includeif.hasconfig:remote.*.url:git@github.com:acme/**.path\n.gitconfig-acme\x00"
+
+	includes := parseIncludes(output)
+	if len(includes) != 2 {
+		t.Fatalf("expected 2 includes, got %+v", includes)
+	}
+	if includes[0].Condition != "gitdir:~/Work/" || includes[0].Path != "/home/me/.gitconfig-work" {
+		t.Errorf("unexpected first include: %+v", includes[0])
+	}
+	if includes[1].Condition != "hasconfig:remote.*.url:git@github.com:acme/**" {
+		t.Errorf("unexpected second condition: %q", includes[1].Condition)
+	}
+}
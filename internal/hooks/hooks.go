@@ -0,0 +1,106 @@
+// Package hooks installs the Git hooks that verify the identity in use.
+package hooks
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/naxodev/github-switch/internal/shell"
+)
+
+// Stages lists the hooks github-switch installs.
+var Stages = []string{"pre-commit", "pre-push"}
+
+// managedMarker identifies hook scripts written by github-switch.
+const managedMarker = "# Installed by github-switch."
+
+const scriptTemplate = `#!/bin/sh
+%[1]s Do not edit; run 'github-switch hooks uninstall' to remove.
+%[2]s hooks verify %[3]s || exit 1
+
+# When installed through core.hooksPath, still run the repository's own hook.
+# --git-path hooks would resolve to core.hooksPath, i.e. this directory.
+repo_hook="$(git rev-parse --git-common-dir)/hooks/%[3]s"
+if [ -x "$repo_hook" ] && [ "$(cd "$(dirname "$repo_hook")" && pwd -P)" != "$(cd "$(dirname "$0")" && pwd -P)" ]; then
+  exec "$repo_hook" "$@"
+fi
+`
+
+// Script returns the hook script for stage that calls back into exe.
+func Script(stage, exe string) string {
+	return fmt.Sprintf(scriptTemplate, managedMarker, shell.Quote(exe), stage)
+}
+
+// IsManaged reports whether the hook at path was written by github-switch.
+// A missing file is not managed.
+func IsManaged(path string) (bool, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to read hook: %w", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for i := 0; i < 3 && scanner.Scan(); i++ {
+		if strings.HasPrefix(scanner.Text(), managedMarker) {
+			return true, nil
+		}
+	}
+	return false, scanner.Err()
+}
+
+// Install writes every hook stage into dir. Existing hooks that were not
+// written by github-switch are only replaced when force is set.
+func Install(dir, exe string, force bool) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create hooks directory: %w", err)
+	}
+
+	for _, stage := range Stages {
+		path := filepath.Join(dir, stage)
+		if !force {
+			if _, err := os.Stat(path); err == nil {
+				managed, err := IsManaged(path)
+				if err != nil {
+					return err
+				}
+				if !managed {
+					return fmt.Errorf("%s already exists and was not installed by github-switch (use --force to replace it)", path)
+				}
+			}
+		}
+
+		if err := os.WriteFile(path, []byte(Script(stage, exe)), 0o755); err != nil {
+			return fmt.Errorf("failed to write %s hook: %w", stage, err)
+		}
+	}
+
+	return nil
+}
+
+// Uninstall removes the hooks in dir that were written by github-switch and
+// leaves any others in place.
+func Uninstall(dir string) error {
+	for _, stage := range Stages {
+		path := filepath.Join(dir, stage)
+		managed, err := IsManaged(path)
+		if err != nil {
+			return err
+		}
+		if !managed {
+			continue
+		}
+		if err := os.Remove(path); err != nil {
+			return fmt.Errorf("failed to remove %s hook: %w", stage, err)
+		}
+	}
+
+	return nil
+}
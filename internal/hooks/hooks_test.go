@@ -0,0 +1,113 @@
+package hooks
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestInstallAndUninstall(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "hooks")
+
+	if err := Install(dir, "/usr/local/bin/github-switch", false); err != nil {
+		t.Fatalf("failed to install hooks: %v", err)
+	}
+
+	for _, stage := range Stages {
+		path := filepath.Join(dir, stage)
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("expected %s hook: %v", stage, err)
+		}
+		if !strings.Contains(string(data), "/usr/local/bin/github-switch hooks verify "+stage) {
+			t.Errorf("%s hook does not call verify:\n%s", stage, data)
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Mode().Perm()&0o100 == 0 {
+			t.Errorf("expected %s hook to be executable, got %o", stage, info.Mode().Perm())
+		}
+	}
+
+	if err := Install(dir, "/usr/local/bin/github-switch", false); err != nil {
+		t.Errorf("expected reinstalling managed hooks to succeed, got: %v", err)
+	}
+
+	if err := Uninstall(dir); err != nil {
+		t.Fatalf("failed to uninstall hooks: %v", err)
+	}
+	for _, stage := range Stages {
+		if _, err := os.Stat(filepath.Join(dir, stage)); !os.IsNotExist(err) {
+			t.Errorf("expected %s hook to be removed", stage)
+		}
+	}
+}
+
+func TestInstallKeepsForeignHooks(t *testing.T) {
+	dir := t.TempDir()
+	foreign := filepath.Join(dir, "pre-commit")
+	if err := os.WriteFile(foreign, []byte("#!/bin/sh\nmake lint\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Install(dir, "github-switch", false); err == nil {
+		t.Fatal("expected an error when a foreign hook exists")
+	}
+
+	if err := Uninstall(dir); err != nil {
+		t.Fatalf("failed to uninstall hooks: %v", err)
+	}
+	if _, err := os.Stat(foreign); err != nil {
+		t.Errorf("expected foreign hook to be kept: %v", err)
+	}
+
+	if err := Install(dir, "github-switch", true); err != nil {
+		t.Fatalf("expected --force install to succeed: %v", err)
+	}
+	managed, err := IsManaged(foreign)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !managed {
+		t.Error("expected forced install to replace the foreign hook")
+	}
+}
+
+func TestGlobalHookRunsRepositoryHook(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+
+	repo := t.TempDir()
+	global := filepath.Join(t.TempDir(), "hooks")
+	marker := filepath.Join(t.TempDir(), "ran")
+
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-C", repo, "-c", "user.name=Me", "-c", "user.email=me@example.com"}, args...)...)
+		if output, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, output)
+		}
+	}
+	git("init", "-q")
+
+	repoHook := "#!/bin/sh\ntouch '" + marker + "'\n"
+	if err := os.WriteFile(filepath.Join(repo, ".git", "hooks", "pre-commit"), []byte(repoHook), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	// true stands in for github-switch so that verification passes.
+	if err := Install(global, "true", false); err != nil {
+		t.Fatalf("failed to install hooks: %v", err)
+	}
+
+	git("-c", "core.hooksPath="+global, "commit", "-q", "--allow-empty", "-m", "test")
+	if _, err := os.Stat(marker); err != nil {
+		t.Error("expected the repository's own pre-commit hook to run")
+	}
+}
@@ -0,0 +1,61 @@
+// Package lock provides an advisory inter-process lock backed by a file.
+package lock
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// ErrLocked is returned when the lock is still held by another process once
+// the timeout expires.
+var ErrLocked = errors.New("another github-switch is running")
+
+// pollInterval is how often Acquire retries a held lock.
+const pollInterval = 25 * time.Millisecond
+
+type Lock struct {
+	f *os.File
+}
+
+// Acquire takes an exclusive lock on path, creating the file if needed, and
+// waits up to timeout for other holders to release it.
+func Acquire(path string, timeout time.Duration) (*Lock, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create lock directory: %w", err)
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open lock file: %w", err)
+	}
+
+	deadline := time.Now().Add(timeout)
+	for {
+		ok, err := tryLock(f)
+		if err != nil {
+			f.Close()
+			return nil, fmt.Errorf("failed to lock %s: %w", path, err)
+		}
+		if ok {
+			return &Lock{f: f}, nil
+		}
+		if time.Now().After(deadline) {
+			f.Close()
+			return nil, fmt.Errorf("%w (waited %s for %s)", ErrLocked, timeout, path)
+		}
+		time.Sleep(pollInterval)
+	}
+}
+
+// Release unlocks and closes the lock file. The file itself is left in
+// place so that every process keeps locking the same inode.
+func (l *Lock) Release() error {
+	if err := unlock(l.f); err != nil {
+		l.f.Close()
+		return fmt.Errorf("failed to unlock: %w", err)
+	}
+	return l.f.Close()
+}
@@ -0,0 +1,14 @@
+//go:build !unix && !windows
+
+package lock
+
+import "os"
+
+// Platforms without file locking run unlocked.
+func tryLock(f *os.File) (bool, error) {
+	return true, nil
+}
+
+func unlock(f *os.File) error {
+	return nil
+}
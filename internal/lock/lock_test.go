@@ -0,0 +1,144 @@
+package lock
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// increment performs an unprotected read-modify-write of a counter file
+// under the lock, yielding in between to widen any race window.
+func increment(lockPath, counterPath string) error {
+	l, err := Acquire(lockPath, 10*time.Second)
+	if err != nil {
+		return err
+	}
+	defer l.Release()
+
+	data, err := os.ReadFile(counterPath)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	n, _ := strconv.Atoi(strings.TrimSpace(string(data)))
+	time.Sleep(time.Millisecond)
+	return os.WriteFile(counterPath, []byte(strconv.Itoa(n+1)), 0o600)
+}
+
+func readCounter(t *testing.T, path string) int {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n, err := strconv.Atoi(string(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+func TestAcquireSerializesGoroutines(t *testing.T) {
+	dir := t.TempDir()
+	lockPath := filepath.Join(dir, "config.lock")
+	counterPath := filepath.Join(dir, "counter")
+
+	const workers = 20
+	var wg sync.WaitGroup
+	errs := make(chan error, workers)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- increment(lockPath, counterPath)
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if n := readCounter(t, counterPath); n != workers {
+		t.Errorf("expected counter %d, got %d (lost updates)", workers, n)
+	}
+}
+
+func TestAcquireTimeout(t *testing.T) {
+	lockPath := filepath.Join(t.TempDir(), "config.lock")
+
+	held, err := Acquire(lockPath, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	if _, err := Acquire(lockPath, 100*time.Millisecond); !errors.Is(err, ErrLocked) {
+		t.Fatalf("expected ErrLocked, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+		t.Errorf("expected Acquire to wait for the timeout, returned after %s", elapsed)
+	}
+
+	if err := held.Release(); err != nil {
+		t.Fatal(err)
+	}
+	l, err := Acquire(lockPath, 100*time.Millisecond)
+	if err != nil {
+		t.Fatalf("expected lock to be free after release: %v", err)
+	}
+	l.Release()
+}
+
+// TestHelperProcess is run as a subprocess by TestAcquireSerializesProcesses.
+func TestHelperProcess(t *testing.T) {
+	if os.Getenv("GITHUB_SWITCH_LOCK_HELPER") != "1" {
+		t.Skip("helper process")
+	}
+	for i := 0; i < 10; i++ {
+		if err := increment(os.Getenv("LOCK_PATH"), os.Getenv("COUNTER_PATH")); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
+	os.Exit(0)
+}
+
+func TestAcquireSerializesProcesses(t *testing.T) {
+	dir := t.TempDir()
+	lockPath := filepath.Join(dir, "config.lock")
+	counterPath := filepath.Join(dir, "counter")
+
+	const processes = 4
+	cmds := make([]*exec.Cmd, processes)
+	for i := range cmds {
+		cmd := exec.Command(os.Args[0], "-test.run=^TestHelperProcess$")
+		cmd.Env = append(os.Environ(),
+			"GITHUB_SWITCH_LOCK_HELPER=1",
+			"LOCK_PATH="+lockPath,
+			"COUNTER_PATH="+counterPath,
+		)
+		if err := cmd.Start(); err != nil {
+			t.Fatal(err)
+		}
+		cmds[i] = cmd
+	}
+
+	for _, cmd := range cmds {
+		if err := cmd.Wait(); err != nil {
+			t.Fatalf("helper process failed: %v", err)
+		}
+	}
+
+	if n := readCounter(t, counterPath); n != processes*10 {
+		t.Errorf("expected counter %d, got %d (lost updates)", processes*10, n)
+	}
+}
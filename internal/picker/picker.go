@@ -0,0 +1,426 @@
+// Package picker implements a full-screen terminal menu with fuzzy search.
+package picker
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/term"
+)
+
+// ErrCancelled is returned by Run when the user leaves without choosing.
+var ErrCancelled = errors.New("cancelled")
+
+// Item is one entry of the menu.
+type Item struct {
+	Name string
+	// Detail is shown next to the name, e.g. an email address.
+	Detail string
+	Tags   []string
+	// Preview holds the lines shown in the preview pane while the item is
+	// selected.
+	Preview []string
+	// Active items are highlighted and marked with *.
+	Active bool
+}
+
+// Match reports whether the runes of pattern appear in text in order,
+// ignoring case, and scores the match. Consecutive runes and runes at the
+// start of a word score higher.
+func Match(pattern, text string) (int, bool) {
+	p := []rune(strings.ToLower(pattern))
+	if len(p) == 0 {
+		return 0, true
+	}
+	t := []rune(strings.ToLower(text))
+
+	score, next, prev := 0, 0, -2
+	for i, r := range t {
+		if next == len(p) {
+			break
+		}
+		if r != p[next] {
+			continue
+		}
+		score++
+		if i == prev+1 {
+			score += 2
+		}
+		if i == 0 || !unicode.IsLetter(t[i-1]) && !unicode.IsDigit(t[i-1]) {
+			score += 3
+		}
+		prev = i
+		next++
+	}
+	if next < len(p) {
+		return 0, false
+	}
+	return score, true
+}
+
+// score matches every space-separated term of query against the name,
+// detail and tags of item, keeping the best field for each term.
+func score(item Item, query string) (int, bool) {
+	fields := append([]string{item.Name, item.Detail}, item.Tags...)
+	total := 0
+	for _, term := range strings.Fields(query) {
+		best, found := 0, false
+		for _, field := range fields {
+			if s, ok := Match(term, field); ok && (!found || s > best) {
+				best, found = s, true
+			}
+		}
+		if !found {
+			return 0, false
+		}
+		total += best
+	}
+	return total, true
+}
+
+type key int
+
+const (
+	keyRune key = iota
+	keyUp
+	keyDown
+	keyPageUp
+	keyPageDown
+	keyHome
+	keyEnd
+	keyEnter
+	keyBackspace
+	keyClear
+	keyCancel
+)
+
+type event struct {
+	key  key
+	rune rune
+}
+
+// parseKeys decodes the bytes of one read from a terminal in raw mode. An
+// escape that ends the read is the Esc key rather than the start of a
+// sequence.
+func parseKeys(b []byte) []event {
+	var events []event
+	for len(b) > 0 {
+		switch c := b[0]; {
+		case c == 0x1b && len(b) > 1 && (b[1] == '[' || b[1] == 'O'):
+			end := 2
+			for end < len(b) && (b[end] < 0x40 || b[end] > 0x7e) {
+				end++
+			}
+			if end == len(b) {
+				return events
+			}
+			if k, ok := escapeKeys[string(b[2:end+1])]; ok {
+				events = append(events, event{key: k})
+			}
+			b = b[end+1:]
+			continue
+		case c == 0x1b && len(b) > 1:
+			// Alt with another key; ignored.
+			b = b[2:]
+			continue
+		case c == 0x1b || c == 0x03:
+			events = append(events, event{key: keyCancel})
+		case c == '\r' || c == '\n':
+			events = append(events, event{key: keyEnter})
+		case c == 0x7f || c == 0x08:
+			events = append(events, event{key: keyBackspace})
+		case c == 0x15:
+			events = append(events, event{key: keyClear})
+		case c == 0x10:
+			events = append(events, event{key: keyUp})
+		case c == 0x0e:
+			events = append(events, event{key: keyDown})
+		case c < 0x20:
+		default:
+			r, size := utf8.DecodeRune(b)
+			if r != utf8.RuneError {
+				events = append(events, event{key: keyRune, rune: r})
+			}
+			b = b[size:]
+			continue
+		}
+		b = b[1:]
+	}
+	return events
+}
+
+// escapeKeys maps the escape sequences of the navigation keys, without
+// their leading ESC [ or ESC O, to keys.
+var escapeKeys = map[string]key{
+	"A":  keyUp,
+	"B":  keyDown,
+	"H":  keyHome,
+	"F":  keyEnd,
+	"1~": keyHome,
+	"7~": keyHome,
+	"4~": keyEnd,
+	"8~": keyEnd,
+	"5~": keyPageUp,
+	"6~": keyPageDown,
+}
+
+type action int
+
+const (
+	stay action = iota
+	chosen
+	cancelled
+)
+
+type model struct {
+	title string
+	items []Item
+	query []rune
+	// matches holds the indexes of the items matching query, best first.
+	matches []int
+	// cursor is the selected position in matches.
+	cursor int
+	// offset is the first position in matches that is shown.
+	offset int
+	// rows is the number of matches the last render had room for.
+	rows int
+	// nameWidth aligns the details of all items.
+	nameWidth int
+}
+
+func newModel(title string, items []Item) *model {
+	m := &model{title: title, items: items, rows: 1}
+	for _, item := range items {
+		m.nameWidth = max(m.nameWidth, utf8.RuneCountInString(item.Name)+2)
+	}
+	m.filter()
+	return m
+}
+
+func (m *model) filter() {
+	query := string(m.query)
+	scores := make(map[int]int, len(m.items))
+	m.matches = m.matches[:0]
+	for i, item := range m.items {
+		if s, ok := score(item, query); ok {
+			scores[i] = s
+			m.matches = append(m.matches, i)
+		}
+	}
+	sort.SliceStable(m.matches, func(a, b int) bool {
+		return scores[m.matches[a]] > scores[m.matches[b]]
+	})
+	m.cursor, m.offset = 0, 0
+}
+
+func (m *model) move(delta int) {
+	m.cursor = max(0, min(m.cursor+delta, len(m.matches)-1))
+}
+
+func (m *model) handle(ev event) action {
+	switch ev.key {
+	case keyUp:
+		m.move(-1)
+	case keyDown:
+		m.move(1)
+	case keyPageUp:
+		m.move(-m.rows)
+	case keyPageDown:
+		m.move(m.rows)
+	case keyHome:
+		m.move(-len(m.matches))
+	case keyEnd:
+		m.move(len(m.matches))
+	case keyEnter:
+		if len(m.matches) > 0 {
+			return chosen
+		}
+	case keyCancel:
+		return cancelled
+	case keyBackspace:
+		if len(m.query) > 0 {
+			m.query = m.query[:len(m.query)-1]
+			m.filter()
+		}
+	case keyClear:
+		m.query = nil
+		m.filter()
+	case keyRune:
+		m.query = append(m.query, ev.rune)
+		m.filter()
+	}
+	return stay
+}
+
+const (
+	altScreenOn  = "\x1b[?1049h"
+	altScreenOff = "\x1b[?1049l"
+	hideCursor   = "\x1b[?25l"
+	showCursor   = "\x1b[?25h"
+	reverse      = "\x1b[7m"
+	activeStyle  = "\x1b[1;32m"
+	dim          = "\x1b[2m"
+	reset        = "\x1b[0m"
+)
+
+// render draws the menu for a terminal of the given size and scrolls the
+// list so that the cursor stays visible. The preview pane sits right of the
+// list, or below it on narrow terminals.
+func (m *model) render(width, height int) string {
+	width, height = max(width, 20), max(height, 6)
+
+	var preview []string
+	if len(m.matches) > 0 {
+		preview = m.items[m.matches[m.cursor]].Preview
+	}
+
+	listWidth, bodyRows := width, height-3
+	side := width >= 70
+	m.rows = bodyRows
+	if side {
+		listWidth = width / 2
+	} else if len(preview) > 0 {
+		m.rows = max(bodyRows-len(preview)-1, bodyRows/2)
+	}
+
+	if m.cursor < m.offset {
+		m.offset = m.cursor
+	}
+	if m.cursor >= m.offset+m.rows {
+		m.offset = m.cursor - m.rows + 1
+	}
+
+	lines := []string{
+		dim + fit(m.title+"  ↑/↓ move · enter select · esc cancel", width) + reset,
+		fit(fmt.Sprintf("> %s", string(m.query)), width-12) + fmt.Sprintf("%12s", fmt.Sprintf("%d/%d", len(m.matches), len(m.items))),
+		dim + strings.Repeat("─", width) + reset,
+	}
+
+	list := make([]string, 0, m.rows)
+	for pos := m.offset; pos < len(m.matches) && pos < m.offset+m.rows; pos++ {
+		list = append(list, m.renderItem(pos, listWidth))
+	}
+	if len(m.matches) == 0 {
+		list = append(list, dim+fit("  no matches", listWidth)+reset)
+	}
+
+	if side {
+		for row := 0; row < bodyRows; row++ {
+			left := strings.Repeat(" ", listWidth)
+			if row < len(list) {
+				left = list[row]
+			}
+			right := ""
+			if row < len(preview) {
+				right = fit(preview[row], width-listWidth-3)
+			}
+			lines = append(lines, left+dim+" │ "+reset+right)
+		}
+	} else {
+		lines = append(lines, list...)
+		for len(lines) < 3+m.rows {
+			lines = append(lines, "")
+		}
+		if len(preview) > 0 {
+			lines = append(lines, dim+strings.Repeat("─", width)+reset)
+			for _, line := range preview {
+				lines = append(lines, fit(line, width))
+			}
+		}
+	}
+	if len(lines) > height {
+		lines = lines[:height]
+	}
+
+	var b strings.Builder
+	b.WriteString("\x1b[H")
+	for i, line := range lines {
+		if i > 0 {
+			b.WriteString("\r\n")
+		}
+		b.WriteString(line + "\x1b[K")
+	}
+	b.WriteString("\x1b[J")
+	return b.String()
+}
+
+func (m *model) renderItem(pos, width int) string {
+	item := m.items[m.matches[pos]]
+	marker := "  "
+	if pos == m.cursor {
+		marker = "> "
+	}
+	name := item.Name
+	if item.Active {
+		name += " *"
+	}
+	text := marker + fit(name, m.nameWidth) + "  " + item.Detail
+	if len(item.Tags) > 0 {
+		text += "  [" + strings.Join(item.Tags, ", ") + "]"
+	}
+	text = fit(text, width)
+
+	switch {
+	case pos == m.cursor:
+		return reverse + text + reset
+	case item.Active:
+		return activeStyle + text + reset
+	}
+	return text
+}
+
+// fit pads or truncates s to exactly width runes.
+func fit(s string, width int) string {
+	if width <= 0 {
+		return ""
+	}
+	runes := []rune(s)
+	if len(runes) > width {
+		return string(runes[:width-1]) + "…"
+	}
+	return s + strings.Repeat(" ", width-len(runes))
+}
+
+// Run shows items full-screen on the terminal in and out under title, and
+// returns the index of the chosen one. The terminal is put in raw mode for
+// the duration and restored before returning.
+func Run(in, out *os.File, title string, items []Item) (int, error) {
+	fd := int(in.Fd())
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		return 0, fmt.Errorf("failed to set up terminal: %w", err)
+	}
+	defer term.Restore(fd, state)
+
+	io.WriteString(out, altScreenOn+hideCursor)
+	defer io.WriteString(out, showCursor+altScreenOff)
+
+	m := newModel(title, items)
+	buf := make([]byte, 64)
+	for {
+		width, height, err := term.GetSize(int(out.Fd()))
+		if err != nil || width == 0 || height == 0 {
+			width, height = 80, 24
+		}
+		io.WriteString(out, m.render(width, height))
+
+		n, err := in.Read(buf)
+		if err != nil {
+			return 0, fmt.Errorf("failed to read input: %w", err)
+		}
+		for _, ev := range parseKeys(buf[:n]) {
+			switch m.handle(ev) {
+			case chosen:
+				return m.matches[m.cursor], nil
+			case cancelled:
+				return 0, ErrCancelled
+			}
+		}
+	}
+}
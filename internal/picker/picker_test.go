@@ -0,0 +1,154 @@
+package picker
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		pattern, text string
+		ok            bool
+	}{
+		{"", "anything", true},
+		{"wrk", "work", true},
+		{"WORK", "work", true},
+		{"kw", "work", false},
+		{"acme", "me@acme.example", true},
+		{"works", "work", false},
+	}
+	for _, tt := range tests {
+		if _, ok := Match(tt.pattern, tt.text); ok != tt.ok {
+			t.Errorf("Match(%q, %q) = %v, want %v", tt.pattern, tt.text, ok, tt.ok)
+		}
+	}
+
+	prefix, _ := Match("cl", "client-a")
+	scattered, _ := Match("cl", "cool")
+	if prefix <= scattered {
+		t.Errorf("expected a prefix match to outscore a scattered one, got %d and %d", prefix, scattered)
+	}
+}
+
+func TestFilter(t *testing.T) {
+	m := newModel("Pick", []Item{
+		{Name: "personal", Detail: "me@example.com"},
+		{Name: "work", Detail: "me@acme.example", Tags: []string{"client"}},
+		{Name: "oss", Detail: "me@oss.example", Tags: []string{"oss", "client"}},
+	})
+	if len(m.matches) != 3 {
+		t.Fatalf("expected every item without a query, got %v", m.matches)
+	}
+
+	for _, r := range "client" {
+		m.handle(event{key: keyRune, rune: r})
+	}
+	if !reflect.DeepEqual(m.matches, []int{1, 2}) {
+		t.Errorf("expected the tagged items in their original order, got %v", m.matches)
+	}
+
+	m.handle(event{key: keyRune, rune: ' '})
+	for _, r := range "acme" {
+		m.handle(event{key: keyRune, rune: r})
+	}
+	if !reflect.DeepEqual(m.matches, []int{1}) {
+		t.Errorf("expected every term to have to match, got %v", m.matches)
+	}
+
+	m.handle(event{key: keyClear})
+	for _, r := range "oss" {
+		m.handle(event{key: keyRune, rune: r})
+	}
+	if len(m.matches) == 0 || m.matches[0] != 2 {
+		t.Errorf("expected the best match first, got %v", m.matches)
+	}
+}
+
+func TestNavigation(t *testing.T) {
+	m := newModel("Pick", []Item{{Name: "a"}, {Name: "b"}, {Name: "c"}})
+
+	m.handle(event{key: keyUp})
+	if m.cursor != 0 {
+		t.Errorf("expected the cursor to stop at the top, got %d", m.cursor)
+	}
+	m.handle(event{key: keyDown})
+	m.handle(event{key: keyDown})
+	m.handle(event{key: keyDown})
+	if m.cursor != 2 {
+		t.Errorf("expected the cursor to stop at the bottom, got %d", m.cursor)
+	}
+	m.handle(event{key: keyHome})
+	if m.cursor != 0 {
+		t.Errorf("expected Home to go to the top, got %d", m.cursor)
+	}
+
+	if got := m.handle(event{key: keyEnter}); got != chosen {
+		t.Errorf("expected Enter to choose, got %v", got)
+	}
+	if got := m.handle(event{key: keyCancel}); got != cancelled {
+		t.Errorf("expected Esc to cancel, got %v", got)
+	}
+
+	for _, r := range "zzz" {
+		m.handle(event{key: keyRune, rune: r})
+	}
+	if got := m.handle(event{key: keyEnter}); got != stay {
+		t.Errorf("expected Enter without matches to be ignored, got %v", got)
+	}
+}
+
+func TestParseKeys(t *testing.T) {
+	got := parseKeys([]byte("w\x1b[A\x1b[B\x1bOH\x1b[6~\x7f\r\x15é\x1b"))
+	want := []event{
+		{key: keyRune, rune: 'w'},
+		{key: keyUp},
+		{key: keyDown},
+		{key: keyHome},
+		{key: keyPageDown},
+		{key: keyBackspace},
+		{key: keyEnter},
+		{key: keyClear},
+		{key: keyRune, rune: 'é'},
+		{key: keyCancel},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseKeys() = %v, want %v", got, want)
+	}
+
+	if got := parseKeys([]byte("\x1b[1;5C\x03")); !reflect.DeepEqual(got, []event{{key: keyCancel}}) {
+		t.Errorf("expected unknown sequences to be skipped, got %v", got)
+	}
+}
+
+func TestRender(t *testing.T) {
+	items := make([]Item, 30)
+	for i := range items {
+		items[i] = Item{Name: fmt.Sprintf("account-%02d", i), Preview: []string{fmt.Sprintf("Preview %02d", i)}}
+	}
+	items[1].Active = true
+	m := newModel("Pick", items)
+
+	screen := m.render(100, 10)
+	if !strings.Contains(screen, "account-01 *") {
+		t.Errorf("expected the active item to be marked:\n%s", screen)
+	}
+	if !strings.Contains(screen, "Preview 00") {
+		t.Errorf("expected the preview of the selected item:\n%s", screen)
+	}
+	if lines := strings.Count(screen, "\r\n") + 1; lines != 10 {
+		t.Errorf("expected the screen to fill 10 lines, got %d", lines)
+	}
+
+	m.handle(event{key: keyEnd})
+	screen = m.render(100, 10)
+	if !strings.Contains(screen, "account-29") || strings.Contains(screen, "account-00") {
+		t.Errorf("expected the list to scroll to the last item:\n%s", screen)
+	}
+
+	screen = m.render(40, 10)
+	if !strings.Contains(screen, "Preview 29") {
+		t.Errorf("expected the preview below the list on a narrow terminal:\n%s", screen)
+	}
+}
@@ -0,0 +1,200 @@
+// Package project resolves which account a directory is bound to.
+package project
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/naxodev/github-switch/internal/config"
+	"github.com/naxodev/github-switch/internal/git"
+	"gopkg.in/yaml.v3"
+)
+
+// MarkerFile is the name of the per-repository file that names an account.
+const MarkerFile = ".github-switch"
+
+// Marker is the content of a marker file. Account is the account the
+// repository requires; Allowed optionally lists other accounts that may be
+// used in it as well.
+type Marker struct {
+	Account string   `yaml:"account"`
+	Allowed []string `yaml:"allowed,omitempty"`
+}
+
+// Binding describes the account a directory resolved to and where the
+// association came from.
+type Binding struct {
+	Account string
+	Allowed []string
+	Source  string
+}
+
+// Permits reports whether account may be used in the bound directory.
+func (b *Binding) Permits(account string) bool {
+	if account == b.Account {
+		return true
+	}
+	for _, allowed := range b.Allowed {
+		if account == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// Accounts returns the required account followed by the allowed ones.
+func (b *Binding) Accounts() []string {
+	return append([]string{b.Account}, b.Allowed...)
+}
+
+// FindMarker walks up from dir looking for a marker file. It returns the
+// parsed marker and its path, or a nil marker when none is found.
+func FindMarker(dir string) (*Marker, string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to resolve directory: %w", err)
+	}
+
+	for {
+		path := filepath.Join(dir, MarkerFile)
+		data, err := os.ReadFile(path)
+		if err == nil {
+			var m Marker
+			if err := yaml.Unmarshal(data, &m); err != nil {
+				return nil, "", fmt.Errorf("failed to parse %s: %w", path, err)
+			}
+			if m.Account == "" {
+				return nil, "", fmt.Errorf("%s does not name an account", path)
+			}
+			return &m, path, nil
+		}
+		if !errors.Is(err, os.ErrNotExist) {
+			return nil, "", fmt.Errorf("failed to read %s: %w", path, err)
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return nil, "", nil
+		}
+		dir = parent
+	}
+}
+
+// MatchDirectory returns the account of the most specific rule whose
+// directory contains dir, along with that rule's key.
+func MatchDirectory(rules map[string]string, dir string) (account, rule string) {
+	bestLen := -1
+	for pattern, acc := range rules {
+		root := expandHome(pattern)
+		if !contains(root, dir) {
+			continue
+		}
+		if len(root) > bestLen {
+			bestLen = len(root)
+			account, rule = acc, pattern
+		}
+	}
+	return account, rule
+}
+
+// Resolve finds the account bound to dir. A marker file takes precedence
+// over remote rules, which take precedence over directory rules. It returns
+// nil when dir is not bound.
+func Resolve(cfg *config.Config, dir string) (*Binding, error) {
+	marker, path, err := FindMarker(dir)
+	if err != nil {
+		return nil, err
+	}
+	if marker != nil {
+		return &Binding{Account: marker.Account, Allowed: marker.Allowed, Source: path}, nil
+	}
+
+	if len(cfg.Remotes) > 0 {
+		binding, err := resolveRemotes(cfg.Remotes, dir)
+		if err != nil {
+			return nil, err
+		}
+		if binding != nil {
+			return binding, nil
+		}
+	}
+
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve directory: %w", err)
+	}
+	if account, rule := MatchDirectory(cfg.Directories, abs); account != "" {
+		return &Binding{Account: account, Source: fmt.Sprintf("directory rule %s", rule)}, nil
+	}
+
+	return nil, nil
+}
+
+// MatchRemote returns the first rule whose pattern matches the remote URL.
+func MatchRemote(rules []config.RemoteRule, url string) (config.RemoteRule, bool) {
+	normalized := git.NormalizeRemote(url)
+	for _, rule := range rules {
+		if matchPattern(rule.Pattern, normalized) {
+			return rule, true
+		}
+	}
+	return config.RemoteRule{}, false
+}
+
+func resolveRemotes(rules []config.RemoteRule, dir string) (*Binding, error) {
+	repo, err := git.RepoRoot(dir)
+	if err != nil || repo == "" {
+		return nil, err
+	}
+
+	remotes, err := git.Remotes(repo)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, remote := range remotes {
+		if rule, ok := MatchRemote(rules, remote.URL); ok {
+			return &Binding{
+				Account: rule.Account,
+				Source:  fmt.Sprintf("remote %s %s matching %s", remote.Name, remote.URL, rule.Pattern),
+			}, nil
+		}
+	}
+	return nil, nil
+}
+
+// matchPattern reports whether a normalized remote matches pattern. The
+// pattern is normalized the same way, so rules may be written as URLs, and
+// * matches any run of characters including slashes. Matching ignores case.
+func matchPattern(pattern, remote string) bool {
+	pattern = git.NormalizeRemote(pattern)
+	expr := "(?i)^" + strings.ReplaceAll(regexp.QuoteMeta(pattern), `\*`, ".*") + "$"
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return false
+	}
+	return re.MatchString(remote)
+}
+
+func contains(root, dir string) bool {
+	rel, err := filepath.Rel(root, dir)
+	if err != nil {
+		return false
+	}
+	return rel == "." || (rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)))
+}
+
+func expandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return filepath.Clean(path)
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return filepath.Clean(path)
+	}
+	return filepath.Join(home, strings.TrimPrefix(path, "~"))
+}
@@ -0,0 +1,162 @@
+package project
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/naxodev/github-switch/internal/config"
+)
+
+func TestResolveMarkerFile(t *testing.T) {
+	root := t.TempDir()
+	nested := filepath.Join(root, "src", "pkg")
+	if err := os.MkdirAll(nested, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	marker := filepath.Join(root, MarkerFile)
+	if err := os.WriteFile(marker, []byte("account: work\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	rules := map[string]string{root: "personal"}
+
+	binding, err := Resolve(&config.Config{Directories: rules}, nested)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if binding == nil {
+		t.Fatal("expected a binding")
+	}
+	if binding.Account != "work" {
+		t.Errorf("expected marker to win with 'work', got '%s'", binding.Account)
+	}
+	if binding.Source != marker {
+		t.Errorf("expected source '%s', got '%s'", marker, binding.Source)
+	}
+}
+
+func TestResolveDirectoryRules(t *testing.T) {
+	root := t.TempDir()
+	client := filepath.Join(root, "work", "client")
+	if err := os.MkdirAll(client, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	rules := map[string]string{
+		filepath.Join(root, "work"):           "work",
+		filepath.Join(root, "work", "client"): "client",
+		filepath.Join(root, "wor"):            "wrong",
+	}
+
+	tests := []struct {
+		dir      string
+		expected string
+	}{
+		{dir: client, expected: "client"},
+		{dir: filepath.Join(root, "work"), expected: "work"},
+		{dir: root, expected: ""},
+	}
+
+	for _, tt := range tests {
+		binding, err := Resolve(&config.Config{Directories: rules}, tt.dir)
+		if err != nil {
+			t.Fatalf("unexpected error for %s: %v", tt.dir, err)
+		}
+		got := ""
+		if binding != nil {
+			got = binding.Account
+		}
+		if got != tt.expected {
+			t.Errorf("%s: expected account '%s', got '%s'", tt.dir, tt.expected, got)
+		}
+	}
+}
+
+func TestFindMarkerRequiresAccount(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, MarkerFile), []byte("# empty\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, err := FindMarker(root); err == nil {
+		t.Error("expected an error for a marker without an account")
+	}
+}
+
+func TestBindingPermits(t *testing.T) {
+	root := t.TempDir()
+	content := "account: client\nallowed:\n  - client-bot\n"
+	if err := os.WriteFile(filepath.Join(root, MarkerFile), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	binding, err := Resolve(&config.Config{}, root)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, name := range []string{"client", "client-bot"} {
+		if !binding.Permits(name) {
+			t.Errorf("expected '%s' to be permitted", name)
+		}
+	}
+	if binding.Permits("personal") {
+		t.Error("expected 'personal' not to be permitted")
+	}
+}
+
+func TestMatchRemote(t *testing.T) {
+	rules := []config.RemoteRule{
+		{Pattern: "github.com:acme-corp/*", Account: "work"},
+		{Pattern: "github.mycorp.com:*", Account: "corp"},
+		{Pattern: "https://github.com/me/*", Account: "personal"},
+	}
+
+	tests := map[string]string{
+		"git@github.com:acme-corp/app.git":         "work",
+		"https://github.com/Acme-Corp/app":         "work",
+		"ssh://git@github.mycorp.com/team/svc.git": "corp",
+		"git@github.com:me/dotfiles.git":           "personal",
+		"git@github.com:someone-else/fork.git":     "",
+		"git@github.com:acme-corp-evil/phish.git":  "",
+	}
+
+	for url, expected := range tests {
+		rule, _ := MatchRemote(rules, url)
+		if rule.Account != expected {
+			t.Errorf("%s: expected account '%s', got '%s'", url, expected, rule.Account)
+		}
+	}
+}
+
+func TestResolveRemoteRules(t *testing.T) {
+	repo := t.TempDir()
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"remote", "add", "upstream", "git@github.com:other/app.git"},
+		{"remote", "add", "origin", "git@github.com:acme-corp/app.git"},
+	} {
+		cmd := exec.Command("git", append([]string{"-C", repo}, args...)...)
+		if output, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, output)
+		}
+	}
+
+	cfg := &config.Config{
+		Directories: map[string]string{repo: "personal"},
+		Remotes: []config.RemoteRule{
+			{Pattern: "github.com:other/*", Account: "other"},
+			{Pattern: "github.com:acme-corp/*", Account: "work"},
+		},
+	}
+
+	binding, err := Resolve(cfg, repo)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if binding == nil || binding.Account != "work" {
+		t.Fatalf("expected origin remote to bind 'work', got %+v", binding)
+	}
+}
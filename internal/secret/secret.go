@@ -0,0 +1,276 @@
+// Package secret stores account tokens encrypted at rest.
+//
+// Tokens are kept in a single file sealed with AES-256-GCM. The key is
+// derived either from a passphrase with scrypt or from the contents of a key
+// file (such as an age identity) with HKDF-SHA256. A fresh nonce is used on
+// every save.
+package secret
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/naxodev/github-switch/internal/xdg"
+	"golang.org/x/crypto/hkdf"
+	"golang.org/x/crypto/scrypt"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	kdfScrypt  = "scrypt"
+	kdfKeyFile = "keyfile"
+
+	keySize  = 32
+	saltSize = 16
+
+	scryptN = 1 << 15
+	scryptR = 8
+	scryptP = 1
+)
+
+// ErrWrongKey is returned when the store cannot be decrypted with the given
+// passphrase or key file.
+var ErrWrongKey = errors.New("wrong passphrase or key file")
+
+// Key is the material an encryption key is derived from.
+type Key struct {
+	kdf      string
+	material []byte
+}
+
+// Passphrase derives the encryption key from a passphrase.
+func Passphrase(passphrase string) Key {
+	return Key{kdf: kdfScrypt, material: []byte(passphrase)}
+}
+
+// KeyFile derives the encryption key from the contents of a file.
+func KeyFile(path string) (Key, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Key{}, fmt.Errorf("failed to read key file: %w", err)
+	}
+	if len(data) < keySize {
+		return Key{}, fmt.Errorf("key file %s is too short", path)
+	}
+	return Key{kdf: kdfKeyFile, material: data}, nil
+}
+
+// file is the on-disk layout of the store.
+type file struct {
+	Version    int    `yaml:"version"`
+	KDF        string `yaml:"kdf"`
+	Salt       []byte `yaml:"salt"`
+	Nonce      []byte `yaml:"nonce"`
+	Ciphertext []byte `yaml:"ciphertext"`
+}
+
+type Store struct {
+	kdf    string
+	salt   []byte
+	key    []byte
+	tokens map[string]string
+}
+
+var storePath string
+
+func init() {
+	setDefaultPath()
+}
+
+func setDefaultPath() {
+	dir, err := xdg.DataHome()
+	if err != nil {
+		storePath = "secrets.yaml"
+		return
+	}
+	storePath = filepath.Join(dir, xdg.App, "secrets.yaml")
+}
+
+// Init locates the store under $XDG_DATA_HOME and moves one left in
+// ~/.github-switch-secrets.yaml by older releases there, returning its old
+// path if it did.
+func Init() (string, error) {
+	setDefaultPath()
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+
+	legacy := filepath.Join(home, ".github-switch-secrets.yaml")
+	moved, err := xdg.MigrateLegacy(legacy, storePath)
+	if err != nil || !moved {
+		return "", err
+	}
+	return legacy, nil
+}
+
+func GetStorePath() string {
+	return storePath
+}
+
+// Exists reports whether a store has been created.
+func Exists() bool {
+	_, err := os.Stat(storePath)
+	return err == nil
+}
+
+// Open decrypts the store with key. A missing store yields an empty one that
+// will be encrypted with key when saved.
+func Open(key Key) (*Store, error) {
+	data, err := os.ReadFile(storePath)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("failed to read secrets: %w", err)
+		}
+		salt := make([]byte, saltSize)
+		if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+			return nil, fmt.Errorf("failed to generate salt: %w", err)
+		}
+		derived, err := deriveKey(key, salt)
+		if err != nil {
+			return nil, err
+		}
+		return &Store{kdf: key.kdf, salt: salt, key: derived, tokens: make(map[string]string)}, nil
+	}
+
+	var f file
+	if err := yaml.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("failed to parse secrets: %w", err)
+	}
+	if f.Version != 1 {
+		return nil, fmt.Errorf("unsupported secrets version %d", f.Version)
+	}
+	if f.KDF != key.kdf {
+		return nil, fmt.Errorf("secrets are protected with a %s, not a %s", describeKDF(f.KDF), describeKDF(key.kdf))
+	}
+
+	derived, err := deriveKey(key, f.Salt)
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := newAEAD(derived)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := aead.Open(nil, f.Nonce, f.Ciphertext, []byte(f.KDF))
+	if err != nil {
+		return nil, ErrWrongKey
+	}
+
+	tokens := make(map[string]string)
+	if err := json.Unmarshal(plaintext, &tokens); err != nil {
+		return nil, fmt.Errorf("failed to decode secrets: %w", err)
+	}
+
+	return &Store{kdf: f.KDF, salt: f.Salt, key: derived, tokens: tokens}, nil
+}
+
+func (s *Store) Save() error {
+	plaintext, err := json.Marshal(s.tokens)
+	if err != nil {
+		return fmt.Errorf("failed to encode secrets: %w", err)
+	}
+
+	aead, err := newAEAD(s.key)
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	data, err := yaml.Marshal(file{
+		Version:    1,
+		KDF:        s.kdf,
+		Salt:       s.salt,
+		Nonce:      nonce,
+		Ciphertext: aead.Seal(nil, nonce, plaintext, []byte(s.kdf)),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal secrets: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(storePath), 0o700); err != nil {
+		return fmt.Errorf("failed to create secrets directory: %w", err)
+	}
+
+	if err := os.WriteFile(storePath, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write secrets: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Store) Get(account string) (string, bool) {
+	token, ok := s.tokens[account]
+	return token, ok
+}
+
+func (s *Store) Set(account, token string) {
+	s.tokens[account] = token
+}
+
+func (s *Store) Delete(account string) bool {
+	if _, ok := s.tokens[account]; !ok {
+		return false
+	}
+	delete(s.tokens, account)
+	return true
+}
+
+// Accounts lists the accounts that have a token, sorted by name.
+func (s *Store) Accounts() []string {
+	names := make([]string, 0, len(s.tokens))
+	for name := range s.tokens {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func deriveKey(key Key, salt []byte) ([]byte, error) {
+	switch key.kdf {
+	case kdfScrypt:
+		derived, err := scrypt.Key(key.material, salt, scryptN, scryptR, scryptP, keySize)
+		if err != nil {
+			return nil, fmt.Errorf("failed to derive key: %w", err)
+		}
+		return derived, nil
+	case kdfKeyFile:
+		derived := make([]byte, keySize)
+		r := hkdf.New(sha256.New, key.material, salt, []byte("github-switch secrets"))
+		if _, err := io.ReadFull(r, derived); err != nil {
+			return nil, fmt.Errorf("failed to derive key: %w", err)
+		}
+		return derived, nil
+	default:
+		return nil, fmt.Errorf("no passphrase or key file provided")
+	}
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+func describeKDF(kdf string) string {
+	if kdf == kdfKeyFile {
+		return "key file"
+	}
+	return "passphrase"
+}
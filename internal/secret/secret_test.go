@@ -0,0 +1,96 @@
+package secret
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestStoreRoundTrip(t *testing.T) {
+	storePath = filepath.Join(t.TempDir(), "secrets.yaml")
+
+	store, err := Open(Passphrase("correct horse"))
+	if err != nil {
+		t.Fatalf("failed to open store: %v", err)
+	}
+	store.Set("work", "ghp_work")
+	store.Set("personal", "ghp_personal")
+	if err := store.Save(); err != nil {
+		t.Fatalf("failed to save store: %v", err)
+	}
+
+	data, err := os.ReadFile(storePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(data, []byte("ghp_work")) {
+		t.Error("expected tokens to be encrypted at rest")
+	}
+
+	reopened, err := Open(Passphrase("correct horse"))
+	if err != nil {
+		t.Fatalf("failed to reopen store: %v", err)
+	}
+	if token, _ := reopened.Get("work"); token != "ghp_work" {
+		t.Errorf("expected token 'ghp_work', got '%s'", token)
+	}
+	if !reopened.Delete("personal") {
+		t.Error("expected Delete to return true")
+	}
+	if got := reopened.Accounts(); len(got) != 1 || got[0] != "work" {
+		t.Errorf("expected only 'work' to remain, got %v", got)
+	}
+}
+
+func TestOpenWrongPassphrase(t *testing.T) {
+	storePath = filepath.Join(t.TempDir(), "secrets.yaml")
+
+	store, err := Open(Passphrase("right"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.Set("work", "ghp_work")
+	if err := store.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Open(Passphrase("wrong")); !errors.Is(err, ErrWrongKey) {
+		t.Errorf("expected ErrWrongKey, got %v", err)
+	}
+}
+
+func TestKeyFile(t *testing.T) {
+	dir := t.TempDir()
+	storePath = filepath.Join(dir, "secrets.yaml")
+	keyPath := filepath.Join(dir, "key.txt")
+	if err := os.WriteFile(keyPath, []byte("AGE-SECRET-KEY-1QQQQQQQQQQQQQQQQQQQQQQQQQQQQQQQQQQQQQQQQQQQQQQQQQQQQQQQ"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	key, err := KeyFile(keyPath)
+	if err != nil {
+		t.Fatalf("failed to read key file: %v", err)
+	}
+	store, err := Open(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.Set("work", "ghp_work")
+	if err := store.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Open(Passphrase("anything")); err == nil {
+		t.Error("expected opening a key-file store with a passphrase to fail")
+	}
+
+	reopened, err := Open(key)
+	if err != nil {
+		t.Fatalf("failed to reopen store: %v", err)
+	}
+	if token, _ := reopened.Get("work"); token != "ghp_work" {
+		t.Errorf("expected token 'ghp_work', got '%s'", token)
+	}
+}
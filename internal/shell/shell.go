@@ -0,0 +1,123 @@
+// Package shell renders snippets for the shells github-switch integrates with.
+package shell
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Supported lists the shells Hook can generate code for.
+var Supported = []string{"bash", "zsh", "fish"}
+
+// ExportShells lists the shells Export and Unset can generate code for.
+var ExportShells = []string{"bash", "zsh", "fish", "powershell"}
+
+// Var is an environment variable assignment.
+type Var struct {
+	Name  string
+	Value string
+}
+
+const bashHook = `_github_switch_hook() {
+  if [[ "$PWD" != "${_GITHUB_SWITCH_LAST_PWD:-}" ]]; then
+    _GITHUB_SWITCH_LAST_PWD="$PWD"
+    %[1]s apply --quiet
+  fi
+}
+if [[ ";${PROMPT_COMMAND:-};" != *";_github_switch_hook;"* ]]; then
+  PROMPT_COMMAND="_github_switch_hook${PROMPT_COMMAND:+;$PROMPT_COMMAND}"
+fi
+`
+
+const zshHook = `_github_switch_hook() {
+  %[1]s apply --quiet
+}
+autoload -Uz add-zsh-hook
+add-zsh-hook chpwd _github_switch_hook
+_github_switch_hook
+`
+
+const fishHook = `function __github_switch_hook --on-variable PWD
+    %[1]s apply --quiet
+end
+__github_switch_hook
+`
+
+// Hook returns a snippet that runs "<exe> apply" whenever the working
+// directory of the given shell changes.
+func Hook(shellName, exe string) (string, error) {
+	var tmpl string
+	switch shellName {
+	case "bash":
+		tmpl = bashHook
+	case "zsh":
+		tmpl = zshHook
+	case "fish":
+		tmpl = fishHook
+	default:
+		return "", fmt.Errorf("unsupported shell '%s' (use %s)", shellName, strings.Join(Supported, ", "))
+	}
+	return fmt.Sprintf(tmpl, Quote(exe)), nil
+}
+
+// Quote single-quotes s if it contains characters a POSIX shell would
+// interpret.
+func Quote(s string) string {
+	if s != "" && !strings.ContainsAny(s, " \t\n'\"\\$`!*?[]{}()<>|&;#~") {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// Export returns statements that set vars in the given shell.
+func Export(shellName string, vars []Var) (string, error) {
+	var b strings.Builder
+	for _, v := range vars {
+		switch shellName {
+		case "bash", "zsh":
+			fmt.Fprintf(&b, "export %s=%s\n", v.Name, Quote(v.Value))
+		case "fish":
+			fmt.Fprintf(&b, "set -gx %s %s;\n", v.Name, fishQuote(v.Value))
+		case "powershell", "pwsh":
+			fmt.Fprintf(&b, "$env:%s = %s\n", v.Name, powershellQuote(v.Value))
+		default:
+			return "", unsupportedExportShell(shellName)
+		}
+	}
+	return b.String(), nil
+}
+
+// Unset returns statements that remove the named variables in the given
+// shell.
+func Unset(shellName string, names []string) (string, error) {
+	var b strings.Builder
+	for _, name := range names {
+		switch shellName {
+		case "bash", "zsh":
+			fmt.Fprintf(&b, "unset %s\n", name)
+		case "fish":
+			fmt.Fprintf(&b, "set -e %s;\n", name)
+		case "powershell", "pwsh":
+			fmt.Fprintf(&b, "Remove-Item Env:%s -ErrorAction SilentlyContinue\n", name)
+		default:
+			return "", unsupportedExportShell(shellName)
+		}
+	}
+	return b.String(), nil
+}
+
+func unsupportedExportShell(shellName string) error {
+	return fmt.Errorf("unsupported shell '%s' (use %s)", shellName, strings.Join(ExportShells, ", "))
+}
+
+// fishQuote single-quotes s for fish, where only \\ and \' are escapes
+// inside single quotes.
+func fishQuote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	return "'" + strings.ReplaceAll(s, "'", `\'`) + "'"
+}
+
+// powershellQuote single-quotes s for PowerShell, doubling embedded quotes.
+func powershellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
@@ -0,0 +1,77 @@
+package shell
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHook(t *testing.T) {
+	for _, name := range Supported {
+		snippet, err := Hook(name, "/opt/my tools/github-switch")
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", name, err)
+		}
+		if !strings.Contains(snippet, "'/opt/my tools/github-switch' apply --quiet") {
+			t.Errorf("%s: expected quoted executable in snippet:\n%s", name, snippet)
+		}
+	}
+
+	if _, err := Hook("tcsh", "github-switch"); err == nil {
+		t.Error("expected an error for an unsupported shell")
+	}
+}
+
+func TestQuote(t *testing.T) {
+	tests := map[string]string{
+		"/usr/bin/github-switch": "/usr/bin/github-switch",
+		"/home/me/my keys/id":    "'/home/me/my keys/id'",
+		"it's":                   `'it'\''s'`,
+		"":                       "''",
+	}
+
+	for input, expected := range tests {
+		if got := Quote(input); got != expected {
+			t.Errorf("Quote(%q): expected %s, got %s", input, expected, got)
+		}
+	}
+}
+
+func TestExport(t *testing.T) {
+	vars := []Var{
+		{Name: "GIT_AUTHOR_NAME", Value: "Jane O'Brien"},
+		{Name: "GIT_SSH_COMMAND", Value: "ssh -i /home/jane/.ssh/id_work -o IdentitiesOnly=yes"},
+	}
+
+	tests := map[string]string{
+		"bash": "export GIT_AUTHOR_NAME='Jane O'\\''Brien'\n" +
+			"export GIT_SSH_COMMAND='ssh -i /home/jane/.ssh/id_work -o IdentitiesOnly=yes'\n",
+		"fish": "set -gx GIT_AUTHOR_NAME 'Jane O\\'Brien';\n" +
+			"set -gx GIT_SSH_COMMAND 'ssh -i /home/jane/.ssh/id_work -o IdentitiesOnly=yes';\n",
+		"powershell": "$env:GIT_AUTHOR_NAME = 'Jane O''Brien'\n" +
+			"$env:GIT_SSH_COMMAND = 'ssh -i /home/jane/.ssh/id_work -o IdentitiesOnly=yes'\n",
+	}
+
+	for shellName, expected := range tests {
+		got, err := Export(shellName, vars)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", shellName, err)
+		}
+		if got != expected {
+			t.Errorf("%s: mismatch:\nexpected:\n%s\ngot:\n%s", shellName, expected, got)
+		}
+	}
+
+	if _, err := Export("cmd.exe", vars); err == nil {
+		t.Error("expected an error for an unsupported shell")
+	}
+}
+
+func TestUnset(t *testing.T) {
+	got, err := Unset("fish", []string{"GIT_AUTHOR_NAME", "GIT_SSH_COMMAND"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != "set -e GIT_AUTHOR_NAME;\nset -e GIT_SSH_COMMAND;\n" {
+		t.Errorf("unexpected output: %q", got)
+	}
+}
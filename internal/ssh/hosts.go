@@ -0,0 +1,107 @@
+package ssh
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Host is a Host block of the SSH config.
+type Host struct {
+	Patterns      []string
+	HostName      string
+	IdentityFiles []string
+}
+
+// Alias returns the first pattern the block applies to.
+func (h Host) Alias() string {
+	if len(h.Patterns) == 0 {
+		return ""
+	}
+	return h.Patterns[0]
+}
+
+// Hosts returns the Host blocks of the SSH config. A missing config has none.
+func Hosts() ([]Host, error) {
+	f, err := os.Open(GetConfigPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read SSH config: %w", err)
+	}
+	defer f.Close()
+
+	return ParseHosts(f)
+}
+
+// ParseHosts reads the Host blocks of an SSH config. Match blocks and
+// options outside any block are ignored.
+func ParseHosts(r io.Reader) ([]Host, error) {
+	var hosts []Host
+	var current *Host
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		keyword, args := splitOption(scanner.Text())
+		switch keyword {
+		case "":
+		case "host":
+			hosts = append(hosts, Host{Patterns: args})
+			current = &hosts[len(hosts)-1]
+		case "match":
+			current = nil
+		case "hostname":
+			if current != nil && len(args) > 0 {
+				current.HostName = args[0]
+			}
+		case "identityfile":
+			if current != nil && len(args) > 0 {
+				current.IdentityFiles = append(current.IdentityFiles, args[0])
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to parse SSH config: %w", err)
+	}
+	return hosts, nil
+}
+
+// splitOption splits a config line into its lower-cased keyword and its
+// arguments. Keywords may be separated from arguments by an equals sign.
+func splitOption(line string) (string, []string) {
+	line = strings.TrimSpace(line)
+	if line == "" || strings.HasPrefix(line, "#") {
+		return "", nil
+	}
+
+	end := strings.IndexAny(line, " \t=")
+	if end < 0 {
+		return strings.ToLower(line), nil
+	}
+
+	rest := strings.TrimLeft(line[end:], " \t")
+	rest = strings.TrimPrefix(rest, "=")
+	return strings.ToLower(line[:end]), splitArgs(rest)
+}
+
+// KeyName converts an IdentityFile path to the key file name accounts use,
+// relative to the SSH directory. It reports false for keys stored elsewhere.
+func KeyName(identityFile string) (string, bool) {
+	path := identityFile
+	if rest, ok := strings.CutPrefix(path, "~/"); ok {
+		path = filepath.Join(environ.Home, rest)
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(environ.SSHDir, path)
+	}
+
+	rel, err := filepath.Rel(environ.SSHDir, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return rel, true
+}
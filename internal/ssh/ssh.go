@@ -8,31 +8,40 @@ import (
 	"os/exec"
 	"path/filepath"
 	"strings"
+
+	"github.com/naxodev/github-switch/internal/env"
+	"github.com/naxodev/github-switch/internal/shell"
 )
 
-func GetConfigPath() (string, error) {
-	home, err := os.UserHomeDir()
-	if err != nil {
-		return "", fmt.Errorf("failed to get home directory: %w", err)
-	}
-	return filepath.Join(home, ".ssh", "config"), nil
+var environ = env.Default()
+
+// SetEnv points the package at the SSH files and executables of e.
+func SetEnv(e *env.Env) {
+	environ = e
+}
+
+func GetConfigPath() string {
+	return environ.SSHConfig
+}
+
+// KeyDir returns the directory holding the SSH keys.
+func KeyDir() string {
+	return environ.SSHDir
 }
 
 func UpdateConfig(sshKey string) error {
-	configPath, err := GetConfigPath()
-	if err != nil {
-		return err
-	}
+	configPath := GetConfigPath()
+	identity := IdentityFile(sshKey)
 
 	input, err := os.ReadFile(configPath)
 	if err != nil {
 		if os.IsNotExist(err) {
-			return createNewConfig(configPath, sshKey)
+			return createNewConfig(configPath, identity)
 		}
 		return fmt.Errorf("failed to read SSH config: %w", err)
 	}
 
-	output, err := updateGitHubBlock(string(input), sshKey)
+	output, err := updateGitHubBlock(string(input), identity)
 	if err != nil {
 		return err
 	}
@@ -44,12 +53,12 @@ func UpdateConfig(sshKey string) error {
 	return nil
 }
 
-func createNewConfig(path, sshKey string) error {
+func createNewConfig(path, identity string) error {
 	content := fmt.Sprintf(`Host github.com
   AddKeysToAgent yes
   UseKeychain yes
-  IdentityFile ~/.ssh/%s
-`, sshKey)
+  IdentityFile %s
+`, identity)
 
 	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
 		return fmt.Errorf("failed to create .ssh directory: %w", err)
@@ -62,7 +71,23 @@ func createNewConfig(path, sshKey string) error {
 	return nil
 }
 
-func updateGitHubBlock(input, sshKey string) (string, error) {
+// IdentityFile returns the IdentityFile value that selects a key in the SSH
+// directory: relative to ~ when the directory is in the home directory,
+// absolute otherwise, and quoted when it contains spaces.
+func IdentityFile(sshKey string) string {
+	path := KeyPath(sshKey)
+	if environ.Home != "" {
+		if rel, err := filepath.Rel(environ.Home, path); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			path = "~/" + filepath.ToSlash(rel)
+		}
+	}
+	if strings.ContainsAny(path, " \t") {
+		return `"` + path + `"`
+	}
+	return path
+}
+
+func updateGitHubBlock(input, identity string) (string, error) {
 	scanner := bufio.NewScanner(strings.NewReader(input))
 	var lines []string
 	inGithubBlock := false
@@ -86,7 +111,7 @@ func updateGitHubBlock(input, sshKey string) (string, error) {
 
 		if inGithubBlock && strings.HasPrefix(trimmed, "IdentityFile") {
 			indent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
-			lines = append(lines, fmt.Sprintf("%sIdentityFile ~/.ssh/%s", indent, sshKey))
+			lines = append(lines, fmt.Sprintf("%sIdentityFile %s", indent, identity))
 			identityUpdated = true
 			continue
 		}
@@ -102,11 +127,11 @@ func updateGitHubBlock(input, sshKey string) (string, error) {
 		lines = append(lines, "", "Host github.com")
 		lines = append(lines, "  AddKeysToAgent yes")
 		lines = append(lines, "  UseKeychain yes")
-		lines = append(lines, fmt.Sprintf("  IdentityFile ~/.ssh/%s", sshKey))
+		lines = append(lines, fmt.Sprintf("  IdentityFile %s", identity))
 	} else if !identityUpdated {
 		for i, line := range lines {
 			if strings.EqualFold(strings.TrimSpace(line), "Host github.com") {
-				insertLines := []string{fmt.Sprintf("  IdentityFile ~/.ssh/%s", sshKey)}
+				insertLines := []string{fmt.Sprintf("  IdentityFile %s", identity)}
 				lines = append(lines[:i+1], append(insertLines, lines[i+1:]...)...)
 				break
 			}
@@ -117,12 +142,7 @@ func updateGitHubBlock(input, sshKey string) (string, error) {
 }
 
 func GetCurrentKey() (string, error) {
-	configPath, err := GetConfigPath()
-	if err != nil {
-		return "", err
-	}
-
-	input, err := os.ReadFile(configPath)
+	input, err := os.ReadFile(GetConfigPath())
 	if err != nil {
 		return "", fmt.Errorf("failed to read SSH config: %w", err)
 	}
@@ -154,17 +174,112 @@ func GetCurrentKey() (string, error) {
 	return "", nil
 }
 
-func AddKeyToAgent(sshKey string) error {
-	home, err := os.UserHomeDir()
-	if err != nil {
-		return fmt.Errorf("failed to get home directory: %w", err)
+// KeyPath returns the path of a key file in the SSH directory.
+func KeyPath(sshKey string) string {
+	return filepath.Join(environ.SSHDir, sshKey)
+}
+
+// Command returns an ssh invocation that authenticates with only the given
+// key, suitable for core.sshCommand or GIT_SSH_COMMAND.
+func Command(sshKey string) string {
+	return fmt.Sprintf("ssh -i %s -o IdentitiesOnly=yes", shell.Quote(KeyPath(sshKey)))
+}
+
+// KeyFromCommand extracts the key file name passed with -i in an ssh command
+// line such as core.sshCommand. It returns an empty string if there is none.
+func KeyFromCommand(command string) string {
+	args := splitArgs(command)
+	for i, arg := range args {
+		switch {
+		case arg == "-i" && i+1 < len(args):
+			return filepath.Base(args[i+1])
+		case strings.HasPrefix(arg, "-i") && len(arg) > 2:
+			return filepath.Base(arg[2:])
+		}
+	}
+	return ""
+}
+
+// splitArgs splits a command line on whitespace, honouring single and
+// double quotes.
+func splitArgs(command string) []string {
+	var args []string
+	var current strings.Builder
+	var quote rune
+	inArg := false
+
+	for _, r := range command {
+		switch {
+		case quote != 0 && r == quote:
+			quote = 0
+		case quote != 0:
+			current.WriteRune(r)
+		case r == '\'' || r == '"':
+			quote = r
+			inArg = true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+		default:
+			current.WriteRune(r)
+			inArg = true
+		}
+	}
+	if inArg {
+		args = append(args, current.String())
 	}
 
-	keyPath := filepath.Join(home, ".ssh", sshKey)
-	cmd := exec.Command("ssh-add", keyPath)
+	return args
+}
+
+func AddKeyToAgent(sshKey string) error {
+	cmd := exec.Command(environ.SSHAdd, KeyPath(sshKey))
 	if err := cmd.Run(); err != nil {
 		return fmt.Errorf("failed to add key to ssh-agent: %w", err)
 	}
 
 	return nil
 }
+
+// KeyInAgent reports whether the given key is currently loaded in ssh-agent.
+// An error is returned when the agent cannot be reached.
+func KeyInAgent(sshKey string) (bool, error) {
+	keyPath := KeyPath(sshKey)
+	if _, err := os.Stat(keyPath + ".pub"); err == nil {
+		keyPath += ".pub"
+	}
+
+	output, err := exec.Command(environ.SSHKeygen, "-lf", keyPath).Output()
+	if err != nil {
+		return false, fmt.Errorf("failed to read key fingerprint: %w", err)
+	}
+	fields := strings.Fields(string(output))
+	if len(fields) < 2 {
+		return false, fmt.Errorf("unexpected ssh-keygen output: %s", strings.TrimSpace(string(output)))
+	}
+
+	output, err = exec.Command(environ.SSHAdd, "-l").Output()
+	if err != nil {
+		// ssh-add exits with 1 when the agent holds no identities.
+		if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 1 {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to query ssh-agent: %w", err)
+	}
+
+	return agentHasFingerprint(string(output), fields[1]), nil
+}
+
+func agentHasFingerprint(agentList, fingerprint string) bool {
+	scanner := bufio.NewScanner(strings.NewReader(agentList))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) >= 2 && fields[1] == fingerprint {
+			return true
+		}
+	}
+	return false
+}
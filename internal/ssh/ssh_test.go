@@ -1,7 +1,11 @@
 package ssh
 
 import (
+	"path/filepath"
+	"strings"
 	"testing"
+
+	"github.com/naxodev/github-switch/internal/env"
 )
 
 func TestUpdateGitHubBlock(t *testing.T) {
@@ -60,7 +64,7 @@ Host github.com
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result, err := updateGitHubBlock(tt.input, tt.sshKey)
+			result, err := updateGitHubBlock(tt.input, "~/.ssh/"+tt.sshKey)
 			if err != nil {
 				t.Fatalf("unexpected error: %v", err)
 			}
@@ -70,3 +74,117 @@ Host github.com
 		})
 	}
 }
+
+func TestAgentHasFingerprint(t *testing.T) {
+	agentList := `256 SHA256:abc123 me@personal (ED25519)
+3072 SHA256:def456 me@work (RSA)
+`
+
+	if !agentHasFingerprint(agentList, "SHA256:def456") {
+		t.Error("expected fingerprint SHA256:def456 to be found")
+	}
+	if agentHasFingerprint(agentList, "SHA256:zzz999") {
+		t.Error("expected fingerprint SHA256:zzz999 not to be found")
+	}
+	if agentHasFingerprint("", "SHA256:abc123") {
+		t.Error("expected empty agent list not to match")
+	}
+}
+
+func TestKeyFromCommand(t *testing.T) {
+	tests := map[string]string{
+		"ssh -i /home/me/.ssh/id_work -o IdentitiesOnly=yes": "id_work",
+		"ssh -i '/home/me/my keys/id_client' -F /dev/null":   "id_client",
+		"ssh -i~/.ssh/id_ed25519":                            "id_ed25519",
+		"ssh -o IdentitiesOnly=yes":                          "",
+		"":                                                   "",
+	}
+
+	for command, expected := range tests {
+		if got := KeyFromCommand(command); got != expected {
+			t.Errorf("KeyFromCommand(%q): expected '%s', got '%s'", command, expected, got)
+		}
+	}
+}
+
+func TestParseHosts(t *testing.T) {
+	input := `# personal
+Host github.com
+  IdentityFile ~/.ssh/id_personal
+
+Host github.com-work work
+  HostName=github.com
+  IdentityFile "~/.ssh/id work"
+
+Match host gitlab.com
+  IdentityFile ~/.ssh/id_gitlab
+
+host *
+	identityfile ~/.ssh/id_default
+`
+	hosts, err := ParseHosts(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(hosts) != 3 {
+		t.Fatalf("expected 3 hosts, got %d: %+v", len(hosts), hosts)
+	}
+
+	work := hosts[1]
+	if work.Alias() != "github.com-work" || len(work.Patterns) != 2 || work.HostName != "github.com" {
+		t.Errorf("unexpected work host: %+v", work)
+	}
+	if len(work.IdentityFiles) != 1 || work.IdentityFiles[0] != "~/.ssh/id work" {
+		t.Errorf("unexpected work identity files: %q", work.IdentityFiles)
+	}
+	if len(hosts[0].IdentityFiles) != 1 {
+		t.Errorf("expected the Match block not to leak into github.com: %q", hosts[0].IdentityFiles)
+	}
+	if hosts[2].Alias() != "*" || len(hosts[2].IdentityFiles) != 1 {
+		t.Errorf("expected lower-case keywords to be parsed: %+v", hosts[2])
+	}
+}
+
+func TestKeyName(t *testing.T) {
+	SetEnv(&env.Env{Home: "/home/me", SSHDir: "/home/me/.ssh"})
+	defer SetEnv(env.Default())
+
+	tests := []struct {
+		identityFile string
+		expected     string
+		ok           bool
+	}{
+		{"~/.ssh/id_work", "id_work", true},
+		{"/home/me/.ssh/keys/id_work", filepath.Join("keys", "id_work"), true},
+		{"id_work", "id_work", true},
+		{"/etc/ssh/id_work", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := KeyName(tt.identityFile)
+		if got != tt.expected || ok != tt.ok {
+			t.Errorf("KeyName(%q) = %q, %v; expected %q, %v", tt.identityFile, got, ok, tt.expected, tt.ok)
+		}
+	}
+}
+
+func TestIdentityFile(t *testing.T) {
+	defer SetEnv(env.Default())
+
+	SetEnv(&env.Env{Home: "/home/me", SSHDir: "/home/me/.ssh"})
+	if got := IdentityFile("id_work"); got != "~/.ssh/id_work" {
+		t.Errorf("expected a key in the home directory to be written relative to ~, got %q", got)
+	}
+
+	SetEnv(&env.Env{Home: "/home/me", SSHDir: "/tmp/sbx/ssh"})
+	if got := IdentityFile("id_work"); got != "/tmp/sbx/ssh/id_work" {
+		t.Errorf("expected the key of another SSH directory to be absolute, got %q", got)
+	}
+	if got := IdentityFile("id work"); got != `"/tmp/sbx/ssh/id work"` {
+		t.Errorf("expected a path with spaces to be quoted, got %q", got)
+	}
+
+	SetEnv(&env.Env{Home: "/home/me", SSHDir: "/home/me2/.ssh"})
+	if got := IdentityFile("id_work"); got != "/home/me2/.ssh/id_work" {
+		t.Errorf("expected a sibling of the home directory not to be relative to ~, got %q", got)
+	}
+}
@@ -0,0 +1,100 @@
+// Package state records which account github-switch last activated.
+package state
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/naxodev/github-switch/internal/xdg"
+	"gopkg.in/yaml.v3"
+)
+
+type State struct {
+	Account    string    `yaml:"account"`
+	SwitchedAt time.Time `yaml:"switched_at"`
+}
+
+var statePath string
+
+func init() {
+	setDefaultPath()
+}
+
+func setDefaultPath() {
+	dir, err := xdg.StateHome()
+	if err != nil {
+		statePath = "state.yaml"
+		return
+	}
+	statePath = filepath.Join(dir, xdg.App, "state.yaml")
+}
+
+// Init locates the state file under $XDG_STATE_HOME and moves one left in
+// ~/.github-switch-state.yaml by older releases there, returning its old
+// path if it did.
+func Init() (string, error) {
+	setDefaultPath()
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+
+	legacy := filepath.Join(home, ".github-switch-state.yaml")
+	moved, err := xdg.MigrateLegacy(legacy, statePath)
+	if err != nil || !moved {
+		return "", err
+	}
+	return legacy, nil
+}
+
+func GetStatePath() string {
+	return statePath
+}
+
+// Load reads the state file. A missing file yields an empty State, meaning
+// no account has been activated by github-switch yet.
+func Load() (*State, error) {
+	data, err := os.ReadFile(statePath)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return &State{}, nil
+		}
+		return nil, fmt.Errorf("failed to read state: %w", err)
+	}
+
+	var st State
+	if err := yaml.Unmarshal(data, &st); err != nil {
+		return nil, fmt.Errorf("failed to parse state: %w", err)
+	}
+
+	return &st, nil
+}
+
+func (s *State) Save() error {
+	data, err := yaml.Marshal(s)
+	if err != nil {
+		return fmt.Errorf("failed to marshal state: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(statePath), 0o700); err != nil {
+		return fmt.Errorf("failed to create state directory: %w", err)
+	}
+
+	if err := os.WriteFile(statePath, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write state: %w", err)
+	}
+
+	return nil
+}
+
+// Clear removes the state file. It is not an error if the file is missing.
+func Clear() error {
+	if err := os.Remove(statePath); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove state: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package state
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestStateRoundTrip(t *testing.T) {
+	statePath = filepath.Join(t.TempDir(), "state.yaml")
+
+	switchedAt := time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC)
+	st := &State{Account: "work", SwitchedAt: switchedAt}
+	if err := st.Save(); err != nil {
+		t.Fatalf("failed to save state: %v", err)
+	}
+
+	loaded, err := Load()
+	if err != nil {
+		t.Fatalf("failed to load state: %v", err)
+	}
+
+	if loaded.Account != "work" {
+		t.Errorf("expected account 'work', got '%s'", loaded.Account)
+	}
+	if !loaded.SwitchedAt.Equal(switchedAt) {
+		t.Errorf("expected switched_at %v, got %v", switchedAt, loaded.SwitchedAt)
+	}
+}
+
+func TestLoadMissingState(t *testing.T) {
+	statePath = filepath.Join(t.TempDir(), "missing.yaml")
+
+	st, err := Load()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if st.Account != "" {
+		t.Errorf("expected no active account, got '%s'", st.Account)
+	}
+}
+
+func TestClear(t *testing.T) {
+	statePath = filepath.Join(t.TempDir(), "state.yaml")
+
+	if err := (&State{Account: "work"}).Save(); err != nil {
+		t.Fatalf("failed to save state: %v", err)
+	}
+	if err := Clear(); err != nil {
+		t.Fatalf("failed to clear state: %v", err)
+	}
+	if err := Clear(); err != nil {
+		t.Errorf("expected clearing a missing state to succeed, got: %v", err)
+	}
+
+	st, err := Load()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if st.Account != "" {
+		t.Errorf("expected state to be cleared, got '%s'", st.Account)
+	}
+}
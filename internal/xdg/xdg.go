@@ -0,0 +1,91 @@
+// Package xdg resolves base directories following the XDG Base Directory
+// specification and moves files out of their legacy locations.
+package xdg
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// App is the subdirectory github-switch uses inside each base directory.
+const App = "github-switch"
+
+// ConfigHome returns $XDG_CONFIG_HOME, defaulting to ~/.config.
+func ConfigHome() (string, error) {
+	return baseDir("XDG_CONFIG_HOME", ".config")
+}
+
+// StateHome returns $XDG_STATE_HOME, defaulting to ~/.local/state.
+func StateHome() (string, error) {
+	return baseDir("XDG_STATE_HOME", filepath.Join(".local", "state"))
+}
+
+// DataHome returns $XDG_DATA_HOME, defaulting to ~/.local/share.
+func DataHome() (string, error) {
+	return baseDir("XDG_DATA_HOME", filepath.Join(".local", "share"))
+}
+
+func baseDir(env, fallback string) (string, error) {
+	if dir := os.Getenv(env); filepath.IsAbs(dir) {
+		return dir, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return filepath.Join(home, fallback), nil
+}
+
+// MigrateLegacy moves the file at legacy to target when target does not
+// exist yet. It reports whether a file was moved.
+func MigrateLegacy(legacy, target string) (bool, error) {
+	if _, err := os.Stat(target); err == nil {
+		return false, nil
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return false, err
+	}
+	if _, err := os.Stat(legacy); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(target), 0o700); err != nil {
+		return false, fmt.Errorf("failed to create %s: %w", filepath.Dir(target), err)
+	}
+
+	if err := os.Rename(legacy, target); err != nil {
+		// Fall back to copying when the rename crosses file systems.
+		if err := copyFile(legacy, target); err != nil {
+			return false, fmt.Errorf("failed to move %s to %s: %w", legacy, target, err)
+		}
+		if err := os.Remove(legacy); err != nil {
+			return false, fmt.Errorf("failed to remove %s: %w", legacy, err)
+		}
+	}
+
+	return true, nil
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(dst)
+		return err
+	}
+	return out.Close()
+}
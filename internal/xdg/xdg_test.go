@@ -0,0 +1,69 @@
+package xdg
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestBaseDirs(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", "/custom/config")
+	t.Setenv("XDG_STATE_HOME", "relative/is/ignored")
+	t.Setenv("XDG_DATA_HOME", "")
+
+	tests := []struct {
+		name     string
+		fn       func() (string, error)
+		expected string
+	}{
+		{"config", ConfigHome, "/custom/config"},
+		{"state", StateHome, filepath.Join(home, ".local", "state")},
+		{"data", DataHome, filepath.Join(home, ".local", "share")},
+	}
+
+	for _, tt := range tests {
+		got, err := tt.fn()
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.name, err)
+		}
+		if got != tt.expected {
+			t.Errorf("%s: expected %s, got %s", tt.name, tt.expected, got)
+		}
+	}
+}
+
+func TestMigrateLegacy(t *testing.T) {
+	dir := t.TempDir()
+	legacy := filepath.Join(dir, ".github-switch.yaml")
+	target := filepath.Join(dir, "config", "github-switch", "config.yaml")
+
+	moved, err := MigrateLegacy(legacy, target)
+	if err != nil || moved {
+		t.Fatalf("expected nothing to migrate, got moved=%v err=%v", moved, err)
+	}
+
+	if err := os.WriteFile(legacy, []byte("accounts: {}\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	moved, err = MigrateLegacy(legacy, target)
+	if err != nil || !moved {
+		t.Fatalf("expected legacy file to be moved, got moved=%v err=%v", moved, err)
+	}
+	if _, err := os.Stat(legacy); !os.IsNotExist(err) {
+		t.Error("expected legacy file to be gone")
+	}
+	data, err := os.ReadFile(target)
+	if err != nil || string(data) != "accounts: {}\n" {
+		t.Errorf("expected target to hold the legacy content, got %q (%v)", data, err)
+	}
+
+	if err := os.WriteFile(legacy, []byte("stale"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	moved, err = MigrateLegacy(legacy, target)
+	if err != nil || moved {
+		t.Errorf("expected an existing target to win, got moved=%v err=%v", moved, err)
+	}
+}